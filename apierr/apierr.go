@@ -0,0 +1,128 @@
+// Package apierr defines the errors handlers return to clients: each has a
+// stable machine-readable code with a fixed HTTP status and is written as an
+// RFC 7807 problem+json document.
+package apierr
+
+import "net/http"
+
+// Code identifies a kind of error. Codes are part of the API: clients branch
+// on them, so existing ones never change meaning.
+type Code string
+
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeValidation           Code = "validation_failed"
+	CodeUnauthenticated      Code = "unauthenticated"
+	CodeInvalidCredentials   Code = "invalid_credentials"
+	CodeInvalidToken         Code = "invalid_token"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not_found"
+	CodeConflict             Code = "conflict"
+	CodeVersionConflict      Code = "version_conflict"
+	CodeSKUExists            Code = "sku_exists"
+	CodeReviewExists         Code = "review_exists"
+	CodeSaleOverlap          Code = "sale_overlap"
+	CodePreconditionRequired Code = "precondition_required"
+	CodePayloadTooLarge      Code = "payload_too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeUnprocessable        Code = "unprocessable"
+	CodeInternal             Code = "internal"
+	CodeUnavailable          Code = "unavailable"
+)
+
+var codes = map[Code]struct {
+	status int
+	title  string
+}{
+	CodeBadRequest:           {http.StatusBadRequest, "Bad request"},
+	CodeValidation:           {http.StatusBadRequest, "Validation failed"},
+	CodeUnauthenticated:      {http.StatusUnauthorized, "Authentication required"},
+	CodeInvalidCredentials:   {http.StatusUnauthorized, "Invalid credentials"},
+	CodeInvalidToken:         {http.StatusUnauthorized, "Invalid token"},
+	CodeForbidden:            {http.StatusForbidden, "Forbidden"},
+	CodeNotFound:             {http.StatusNotFound, "Not found"},
+	CodeConflict:             {http.StatusConflict, "Conflict"},
+	CodeVersionConflict:      {http.StatusConflict, "Version conflict"},
+	CodeSKUExists:            {http.StatusConflict, "SKU already exists"},
+	CodeReviewExists:         {http.StatusConflict, "Review already exists"},
+	CodeSaleOverlap:          {http.StatusConflict, "Sale overlaps"},
+	CodePreconditionRequired: {http.StatusPreconditionRequired, "Precondition required"},
+	CodePayloadTooLarge:      {http.StatusRequestEntityTooLarge, "Payload too large"},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	CodeUnprocessable:        {http.StatusUnprocessableEntity, "Unprocessable entity"},
+	CodeInternal:             {http.StatusInternalServerError, "Internal server error"},
+	CodeUnavailable:          {http.StatusServiceUnavailable, "Service unavailable"},
+}
+
+// Status is the HTTP status code responses with c carry.
+func (c Code) Status() int {
+	if info, ok := codes[c]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title is the short summary of c shown in problem documents.
+func (c Code) Title() string {
+	if info, ok := codes[c]; ok {
+		return info.title
+	}
+	return codes[CodeInternal].title
+}
+
+// Error is an error safe to show to clients. Detail is written as is; Err,
+// the underlying cause, is only logged.
+type Error struct {
+	Code   Code
+	Detail string
+	// Fields maps request fields to what is wrong with them.
+	Fields map[string]string
+	// Extensions are extra members of the problem document, such as the
+	// current state of a resource on a version conflict.
+	Extensions map[string]interface{}
+	Err        error
+}
+
+// New returns an error with code and a human-readable detail.
+func New(code Code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+// Internal hides err behind a generic message.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Detail: "Internal server error", Err: err}
+}
+
+// Validation reports per-field problems.
+func Validation(detail string, fields map[string]string) *Error {
+	return &Error{Code: CodeValidation, Detail: detail, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status is the HTTP status code of e's code.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// With adds an extension member and returns e.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extensions == nil {
+		e.Extensions = map[string]interface{}{}
+	}
+	e.Extensions[key] = value
+	return e
+}
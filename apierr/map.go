@@ -0,0 +1,73 @@
+package apierr
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"image-server/money"
+	"image-server/reponsitory"
+	"image-server/shipping"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+)
+
+// known maps the errors repositories and domain packages return to API
+// errors, so handlers can pass them straight to Write.
+var known = []struct {
+	err    error
+	code   Code
+	detail string
+}{
+	{mongo.ErrNoDocuments, CodeNotFound, "Resource not found"},
+	{gridfs.ErrFileNotFound, CodeNotFound, "File not found"},
+	{primitive.ErrInvalidHex, CodeNotFound, "Resource not found"},
+	{reponsitory.ErrVersionConflict, CodeVersionConflict, "Resource was modified by someone else"},
+	{reponsitory.ErrReviewExists, CodeReviewExists, "You have already reviewed this product"},
+	{reponsitory.ErrSaleOverlap, CodeSaleOverlap, "Sale overlaps another sale of this product"},
+	{money.ErrInvalidAmount, CodeBadRequest, "Invalid amount"},
+	{money.ErrInvalidCurrency, CodeBadRequest, "Invalid currency"},
+	{money.ErrCurrencyMismatch, CodeBadRequest, "Currency mismatch"},
+	{shipping.ErrNoRate, CodeUnprocessable, "No shipping rate available for this destination"},
+	{context.DeadlineExceeded, CodeUnavailable, "The request timed out"},
+}
+
+// From returns err as an *Error: API errors pass through, known repository
+// and domain errors get their code, and anything else is internal.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, k := range known {
+		if errors.Is(err, k.err) {
+			return &Error{Code: k.code, Detail: k.detail, Err: err}
+		}
+	}
+	// A malformed ObjectID names no resource.
+	var invalidByte hex.InvalidByteError
+	if errors.As(err, &invalidByte) {
+		return &Error{Code: CodeNotFound, Detail: "Resource not found", Err: err}
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return &Error{Code: CodeConflict, Detail: "Resource already exists", Err: err}
+	}
+	if mongo.IsTimeout(err) {
+		return &Error{Code: CodeUnavailable, Detail: "The database did not respond in time", Err: err}
+	}
+	return Internal(err)
+}
+
+// NotFound is From with detail describing what was not found, e.g.
+// "Product not found".
+func NotFound(err error, detail string) *Error {
+	e := From(err)
+	if e.Code == CodeNotFound {
+		e = &Error{Code: CodeNotFound, Detail: detail, Err: err}
+	}
+	return e
+}
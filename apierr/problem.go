@@ -0,0 +1,82 @@
+package apierr
+
+import (
+	"encoding/json"
+	"image-server/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of problem documents.
+const ContentType = "application/problem+json"
+
+// TypePrefix starts the type URI of every problem; the code follows.
+const TypePrefix = "urn:image-server:problem:"
+
+// Problem is an RFC 7807 problem details document. Code, RequestID and
+// Fields are extension members, as are the entries of Extensions.
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Code       Code                   `json:"code"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Fields     map[string]string      `json:"fields,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON writes Extensions alongside the standard members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	body, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return body, err
+	}
+	members := map[string]interface{}{}
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	var standard map[string]interface{}
+	if err := json.Unmarshal(body, &standard); err != nil {
+		return nil, err
+	}
+	for k, v := range standard {
+		members[k] = v
+	}
+	return json.Marshal(members)
+}
+
+// Problem describes e for the request at instance.
+func (e *Error) Problem(instance, requestID string) Problem {
+	return Problem{
+		Type:       TypePrefix + string(e.Code),
+		Title:      e.Code.Title(),
+		Status:     e.Status(),
+		Detail:     e.Detail,
+		Instance:   instance,
+		Code:       e.Code,
+		RequestID:  requestID,
+		Fields:     e.Fields,
+		Extensions: e.Extensions,
+	}
+}
+
+// Write sends err as a problem document. Server-side failures are logged
+// with their cause, which never reaches the client.
+func Write(c *gin.Context, err error) {
+	e := From(err)
+	_ = c.Error(err)
+	if e.Status() >= 500 && e.Err != nil {
+		logging.FromContext(c.Request.Context()).Error("request failed", "code", string(e.Code), "error", err)
+	}
+	c.Header("Content-Type", ContentType)
+	c.JSON(e.Status(), e.Problem(c.Request.URL.Path, c.GetString("request_id")))
+}
+
+// Abort is Write for middleware: it also stops the handler chain.
+func Abort(c *gin.Context, err error) {
+	c.Abort()
+	Write(c, err)
+}
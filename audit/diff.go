@@ -0,0 +1,67 @@
+// Package audit computes the field-level changes recorded in the audit log.
+package audit
+
+import (
+	"image-server/model"
+	"reflect"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Redacted replaces the values of secret fields in recorded changes.
+const Redacted = "[redacted]"
+
+var secretFields = map[string]bool{"password": true}
+
+// ignoredFields change on every write and would only add noise.
+var ignoredFields = map[string]bool{"_id": true, "updated_at": true, "version": true}
+
+func toMap(v interface{}) (bson.M, error) {
+	if v == nil {
+		return bson.M{}, nil
+	}
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Diff compares the BSON form of two documents and returns the changed
+// fields sorted by name. Pass nil as before for a create and as after for a
+// delete.
+func Diff(before, after interface{}) ([]model.FieldChange, error) {
+	b, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]bool{}
+	for k := range b {
+		fields[k] = true
+	}
+	for k := range a {
+		fields[k] = true
+	}
+	changes := []model.FieldChange{}
+	for field := range fields {
+		if ignoredFields[field] || reflect.DeepEqual(b[field], a[field]) {
+			continue
+		}
+		change := model.FieldChange{Field: field, Before: b[field], After: a[field]}
+		if secretFields[field] {
+			change.Before, change.After = Redacted, Redacted
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
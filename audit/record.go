@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"image-server/logging"
+	"image-server/model"
+	"image-server/reponsitory"
+	"time"
+)
+
+// Record stores entry with the changes from before to after. before is nil
+// for a create and after is nil for a delete; an update without field
+// changes is not recorded. Failures are logged and never returned, so
+// auditing cannot fail the change it describes.
+func Record(ctx context.Context, repo reponsitory.AuditRepo, entry model.AuditEntry, before, after interface{}) {
+	if repo == nil {
+		return
+	}
+	changes, err := Diff(before, after)
+	if err != nil {
+		logging.FromContext(ctx).Error("diff audit entry", "entity", entry.Entity, "entity_id", entry.EntityID, "error", err)
+		return
+	}
+	entry.Changes = changes
+	RecordChanges(ctx, repo, entry)
+}
+
+// RecordChanges stores entry with the changes it already holds.
+func RecordChanges(ctx context.Context, repo reponsitory.AuditRepo, entry model.AuditEntry) {
+	if repo == nil || entry.Action == model.AuditUpdate && len(entry.Changes) == 0 {
+		return
+	}
+	entry.Created_At = time.Now()
+	if _, err := repo.Create(ctx, entry); err != nil {
+		logging.FromContext(ctx).Error("record audit entry", "entity", entry.Entity, "entity_id", entry.EntityID, "error", err)
+	}
+}
@@ -0,0 +1,190 @@
+package catalog
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image-server/model"
+	"image-server/reponsitory"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const FormatXML Format = "xml"
+
+// ParseExportFormat accepts the import formats plus the XML product feed.
+func ParseExportFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "xml", "rss", "feed":
+		return FormatXML, nil
+	}
+	return ParseFormat(s)
+}
+
+// ContentType is the MIME type an export in format is served with.
+func ContentType(format Format) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXML:
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+var csvColumns = []string{
+	"id", "sku", "productname", "brand", "description", "quantity", "price",
+	"currency", "tax_class", "weight", "length", "width", "height", "image_url",
+}
+
+type Exporter struct {
+	Products reponsitory.ProductRepo
+	// BaseURL is the public origin, e.g. "https://shop.example.com", used to
+	// build absolute product and image links.
+	BaseURL string
+	Title   string
+}
+
+func NewExporter(products reponsitory.ProductRepo, baseURL string) *Exporter {
+	return &Exporter{Products: products, BaseURL: strings.TrimRight(baseURL, "/"), Title: "Product feed"}
+}
+
+// ImageURL turns the GridFS file ID stored in ProductImage_URL into an
+// absolute URL served by ServeImageProduct.
+func (e *Exporter) ImageURL(product model.Product) string {
+	if product.ProductImage_URL == "" {
+		return ""
+	}
+	return e.BaseURL + "/image2/" + product.ProductImage_URL
+}
+
+func (e *Exporter) ProductURL(product model.Product) string {
+	return e.BaseURL + "/api/product/get/" + product.ID.Hex()
+}
+
+// Export streams the catalog to w one product at a time.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, format Format) error {
+	buffered := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case FormatCSV:
+		err = e.exportCSV(ctx, buffered)
+	case FormatJSON:
+		err = e.exportJSON(ctx, buffered)
+	case FormatXML:
+		err = e.exportXML(ctx, buffered)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (e *Exporter) exportCSV(ctx context.Context, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	err := e.Products.Each(ctx, func(p model.Product) error {
+		return writer.Write([]string{
+			p.ID.Hex(), p.SKU, p.ProductName, p.Brand, p.Description,
+			strconv.Itoa(p.Quantity), p.Price.Decimal(), p.Price.Currency, p.TaxClass,
+			formatFloat(p.Weight), formatFloat(p.Length), formatFloat(p.Width), formatFloat(p.Height),
+			e.ImageURL(p),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type exportedProduct struct {
+	model.ProductResponse
+	ImageURL string `json:"image_url"`
+}
+
+func (e *Exporter) exportJSON(ctx context.Context, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return e.Products.Each(ctx, func(p model.Product) error {
+		return encoder.Encode(exportedProduct{ProductResponse: p.Response(), ImageURL: e.ImageURL(p)})
+	})
+}
+
+// feedItem follows the Google Merchant Center RSS 2.0 product feed.
+type feedItem struct {
+	XMLName        xml.Name `xml:"item"`
+	ID             string   `xml:"g:id"`
+	Title          string   `xml:"title"`
+	Description    string   `xml:"description"`
+	Link           string   `xml:"link"`
+	ImageLink      string   `xml:"g:image_link,omitempty"`
+	Availability   string   `xml:"g:availability"`
+	Price          string   `xml:"g:price"`
+	SalePrice      string   `xml:"g:sale_price,omitempty"`
+	Brand          string   `xml:"g:brand,omitempty"`
+	Condition      string   `xml:"g:condition"`
+	ShippingWeight string   `xml:"g:shipping_weight,omitempty"`
+}
+
+func (e *Exporter) exportXML(ctx context.Context, w io.Writer) error {
+	header := xml.Header + `<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0"><channel>`
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	channel := []struct {
+		name, value string
+	}{{"title", e.Title}, {"link", e.BaseURL}, {"description", e.Title}}
+	for _, field := range channel {
+		if err := encoder.EncodeElement(field.value, xml.StartElement{Name: xml.Name{Local: field.name}}); err != nil {
+			return err
+		}
+	}
+	err := e.Products.Each(ctx, func(p model.Product) error {
+		item := feedItem{
+			ID:           p.ID.Hex(),
+			Title:        p.ProductName,
+			Description:  p.Description,
+			Link:         e.ProductURL(p),
+			ImageLink:    e.ImageURL(p),
+			Availability: "out_of_stock",
+			Price:        p.Price.String(),
+			Brand:        p.Brand,
+			Condition:    "new",
+		}
+		if p.SKU != "" {
+			item.ID = p.SKU
+		}
+		if p.Quantity > 0 {
+			item.Availability = "in_stock"
+		}
+		if p.Sale.ActiveAt(time.Now()) {
+			item.SalePrice = p.Sale.Price.String()
+		}
+		if p.Weight > 0 {
+			item.ShippingWeight = formatFloat(p.Weight) + " kg"
+		}
+		return encoder.Encode(item)
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "</channel></rss>\n")
+	return err
+}
@@ -0,0 +1,33 @@
+package catalog
+
+import (
+	"context"
+	"image-server/audit"
+	"image-server/model"
+	"image-server/reponsitory"
+)
+
+// ProductHooks runs the side effects of every product create and update,
+// whether made through the API or an import.
+type ProductHooks struct {
+	AuditRepo reponsitory.AuditRepo
+	// OnBackInStock is called when a write raises quantity from zero.
+	OnBackInStock func(ctx context.Context, product model.Product)
+}
+
+// Written records the audit entry of a write by actor and, when quantity
+// rose from zero, notifies back-in-stock subscribers. before is nil for a
+// create.
+func (h ProductHooks) Written(ctx context.Context, actor, requestID string, before *model.Product, after model.Product) {
+	entry := model.AuditEntry{Entity: "product", EntityID: after.ID.Hex(), Actor: actor, RequestID: requestID}
+	if before == nil {
+		entry.Action = model.AuditCreate
+		audit.Record(ctx, h.AuditRepo, entry, nil, after)
+		return
+	}
+	entry.Action = model.AuditUpdate
+	audit.Record(ctx, h.AuditRepo, entry, *before, after)
+	if before.Quantity <= 0 && after.Quantity > 0 && h.OnBackInStock != nil {
+		h.OnBackInStock(ctx, after)
+	}
+}
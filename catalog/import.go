@@ -0,0 +1,350 @@
+// Package catalog bulk imports and exports the products collection.
+package catalog
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image-server/metrics"
+	"image-server/model"
+	"image-server/money"
+	"image-server/reponsitory"
+	"image-server/storage"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat accepts csv and the usual names of JSON lines.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "csv":
+		return FormatCSV, nil
+	case "json", "jsonl", "ndjson":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unsupported format %q", s)
+}
+
+type ImportOptions struct {
+	Format Format
+	DryRun bool
+	// ImageDir is where the "image" column is resolved. Rows naming an image
+	// fail when it is empty.
+	ImageDir string
+}
+
+type RowError struct {
+	Line   int      `json:"line"`
+	SKU    string   `json:"sku,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+type ImportReport struct {
+	DryRun  bool       `json:"dry_run"`
+	Rows    int        `json:"rows"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors"`
+}
+
+type Importer struct {
+	Products reponsitory.ProductRepo
+	DB       *mongo.Database
+	// Prices, when set, records price changes made by the import.
+	Prices reponsitory.PriceRepo
+	// Saved, when set, is called after each product the import writes, with
+	// before nil for a created product.
+	Saved func(ctx context.Context, before *model.Product, after model.Product)
+}
+
+func NewImporter(products reponsitory.ProductRepo, db *mongo.Database) *Importer {
+	return &Importer{Products: products, DB: db}
+}
+
+// recordReader yields one record per row keyed by lower-case column name.
+type recordReader interface {
+	Next() (line int, record map[string]string, err error)
+}
+
+type csvRecords struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVRecords(r io.Reader) (*csvRecords, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+	return &csvRecords{r: reader, header: header}, nil
+}
+
+func (c *csvRecords) Next() (int, map[string]string, error) {
+	fields, err := c.r.Read()
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return parseErr.Line, nil, err
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	line, _ := c.r.FieldPos(0)
+	if len(fields) != len(c.header) {
+		return line, nil, fmt.Errorf("expected %d columns, got %d", len(c.header), len(fields))
+	}
+	record := make(map[string]string, len(fields))
+	for i, field := range fields {
+		record[c.header[i]] = strings.TrimSpace(field)
+	}
+	return line, record, nil
+}
+
+type jsonRecords struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONRecords(r io.Reader) *jsonRecords {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return &jsonRecords{scanner: scanner}
+}
+
+func (j *jsonRecords) Next() (int, map[string]string, error) {
+	for j.scanner.Scan() {
+		j.line++
+		text := strings.TrimSpace(j.scanner.Text())
+		if text == "" {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var raw map[string]interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			return j.line, nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		record := make(map[string]string, len(raw))
+		for key, value := range raw {
+			if value == nil {
+				continue
+			}
+			record[strings.ToLower(key)] = strings.TrimSpace(fmt.Sprint(value))
+		}
+		return j.line, record, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	return 0, nil, io.EOF
+}
+
+// Import upserts products by SKU row by row. Row problems are collected in
+// the report; the returned error is reserved for unreadable input.
+func (im *Importer) Import(ctx context.Context, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	var records recordReader
+	switch opts.Format {
+	case FormatCSV:
+		csvReader, err := newCSVRecords(r)
+		if err != nil {
+			return ImportReport{}, err
+		}
+		records = csvReader
+	case FormatJSON:
+		records = newJSONRecords(r)
+	default:
+		return ImportReport{}, fmt.Errorf("unsupported format %q", opts.Format)
+	}
+	report := ImportReport{DryRun: opts.DryRun, Errors: []RowError{}}
+	seen := map[string]int{}
+	for {
+		line, record, err := records.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && line == 0 {
+			return report, err
+		}
+		report.Rows++
+		if err != nil {
+			report.Failed++
+			report.Errors = append(report.Errors, RowError{Line: line, Errors: []string{err.Error()}})
+			continue
+		}
+		sku := record["sku"]
+		if first, ok := seen[sku]; ok && sku != "" {
+			report.Failed++
+			report.Errors = append(report.Errors, RowError{Line: line, SKU: sku, Errors: []string{fmt.Sprintf("duplicate SKU, first seen on line %d", first)}})
+			continue
+		}
+		seen[sku] = line
+		created, errs := im.importRow(ctx, record, opts)
+		if len(errs) > 0 {
+			report.Failed++
+			report.Errors = append(report.Errors, RowError{Line: line, SKU: sku, Errors: errs})
+			continue
+		}
+		if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+	return report, nil
+}
+
+// importRow validates one record and, unless dry-running, writes it. It
+// reports whether the row creates a new product.
+func (im *Importer) importRow(ctx context.Context, record map[string]string, opts ImportOptions) (bool, []string) {
+	var errs []string
+	sku := record["sku"]
+	if sku == "" {
+		return false, []string{"sku is required"}
+	}
+	product, err := im.Products.FindBySKU(ctx, sku)
+	created := err == mongo.ErrNoDocuments
+	if err != nil && !created {
+		return false, []string{err.Error()}
+	}
+	if !created && product.Deleted_At != nil {
+		return false, []string{"SKU belongs to a deleted product, restore it first"}
+	}
+	original := product
+	if created {
+		product = model.Product{SKU: sku, Created_At: time.Now()}
+		for _, column := range []string{"productname", "quantity", "price"} {
+			if record[column] == "" {
+				errs = append(errs, column+" is required for new products")
+			}
+		}
+	}
+	if v := record["productname"]; v != "" {
+		product.ProductName = v
+	}
+	if v := record["brand"]; v != "" {
+		product.Brand = v
+	}
+	if v := record["description"]; v != "" {
+		product.Description = v
+	}
+	if v := record["tax_class"]; v != "" {
+		product.TaxClass = v
+	}
+	if v := record["quantity"]; v != "" {
+		quantity, err := strconv.Atoi(v)
+		if err != nil || quantity < 0 {
+			errs = append(errs, "invalid quantity")
+		}
+		product.Quantity = quantity
+	}
+	if v := record["price"]; v != "" {
+		currency := record["currency"]
+		if currency == "" {
+			currency = product.Price.Currency
+		}
+		price, err := money.Parse(v, currency)
+		if err != nil || price.IsNegative() {
+			errs = append(errs, "invalid price")
+		}
+		product.Price = price
+	}
+	for _, field := range []struct {
+		name  string
+		value *float64
+	}{
+		{"weight", &product.Weight},
+		{"length", &product.Length},
+		{"width", &product.Width},
+		{"height", &product.Height},
+	} {
+		if v := record[field.name]; v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				errs = append(errs, "invalid "+field.name)
+			}
+			*field.value = f
+		}
+	}
+	imagePath := ""
+	if image := record["image"]; image != "" {
+		if opts.ImageDir == "" || filepath.Base(image) != image {
+			errs = append(errs, "image must be a file name inside the import image directory")
+		} else {
+			imagePath = filepath.Join(opts.ImageDir, image)
+			if info, err := os.Stat(imagePath); err != nil || info.IsDir() {
+				errs = append(errs, "image "+image+" not found")
+			}
+		}
+	}
+	if len(errs) > 0 || opts.DryRun {
+		return created, errs
+	}
+	if imagePath != "" {
+		file, err := os.Open(imagePath)
+		if err != nil {
+			return created, []string{err.Error()}
+		}
+		fileID, _, err := storage.UploadImage(ctx, im.DB, storage.ProductBucket, filepath.Base(imagePath), file)
+		file.Close()
+		if err != nil {
+			return created, []string{"could not upload image: " + err.Error()}
+		}
+		product.ProductImage_URL = fileID
+	}
+	product.Updated_At = time.Now()
+	if created {
+		if product, err = im.Products.Create(ctx, product); err == nil {
+			metrics.ProductsCreated.Inc()
+		}
+	} else {
+		product, err = im.Products.Patch(ctx, original, product)
+	}
+	// Only the sku_1 index means another product has the SKU; the slug
+	// index is unique too.
+	if mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "index: sku_1 ") {
+		return created, []string{"SKU already exists"}
+	}
+	if err != nil {
+		return created, []string{err.Error()}
+	}
+	if im.Saved != nil {
+		if created {
+			im.Saved(ctx, nil, product)
+		} else {
+			im.Saved(ctx, &original, product)
+		}
+	}
+	if !created && im.Prices != nil && original.Price != product.Price {
+		err := im.Prices.RecordChange(ctx, model.PriceHistory{
+			ProductID:  product.ID,
+			Previous:   original.Price,
+			Price:      product.Price,
+			Reason:     model.PriceReasonImport,
+			Changed_At: product.Updated_At,
+		})
+		if err != nil {
+			return created, []string{"could not record price history: " + err.Error()}
+		}
+	}
+	return created, nil
+}
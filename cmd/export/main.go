@@ -0,0 +1,56 @@
+// Command export streams the product catalog as CSV, NDJSON or an RSS feed.
+//
+//	go run ./cmd/export -format xml -base-url https://shop.example.com -out feed.xml
+package main
+
+import (
+	"context"
+	"flag"
+	"image-server/catalog"
+	"image-server/config"
+	"image-server/db"
+	"image-server/reponsitory"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	format := flag.String("format", "csv", "csv, ndjson or xml")
+	out := flag.String("out", "", "output file (default: stdout)")
+	baseURL := flag.String("base-url", "", "public origin for absolute links (default: public_base_url)")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.ValidateDatabase(); err != nil {
+		log.Fatal(err)
+	}
+	f, err := catalog.ParseExportFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *baseURL == "" {
+		*baseURL = cfg.PublicBaseURL
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	client, err := db.ConnectDB(cfg.MongoURI)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	database := client.Database(cfg.DBName)
+	exporter := catalog.NewExporter(reponsitory.NewProductRepo(database), *baseURL)
+	if err := exporter.Export(context.Background(), w, f); err != nil {
+		log.Fatal(err)
+	}
+}
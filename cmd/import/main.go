@@ -0,0 +1,84 @@
+// Command import bulk upserts products by SKU from a CSV or JSON lines file.
+//
+//	go run ./cmd/import -file products.csv -images ./images -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"image-server/catalog"
+	"image-server/config"
+	"image-server/db"
+	"image-server/model"
+	"image-server/notify"
+	"image-server/reponsitory"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV or JSON lines file to import")
+	format := flag.String("format", "", "csv or json (default: from the file extension)")
+	images := flag.String("images", "", "directory holding the files named in the image column (default: import_image_dir)")
+	dryRun := flag.Bool("dry-run", false, "validate without writing")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := cfg.ValidateDatabase(); err != nil {
+		log.Fatal(err)
+	}
+	cfg.Apply()
+	if *images == "" {
+		*images = cfg.ImportImageDir
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+	}
+	f, err := catalog.ParseFormat(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	input, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+
+	client, err := db.ConnectDB(cfg.MongoURI)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	database := client.Database(cfg.DBName)
+	importer := catalog.NewImporter(reponsitory.NewProductRepo(database), database)
+	importer.Prices = reponsitory.NewPriceRepo(database)
+	hooks := catalog.ProductHooks{
+		AuditRepo:     reponsitory.NewAuditRepo(database),
+		OnBackInStock: notify.NewBackInStock(reponsitory.NewWishlistRepo(database), reponsitory.NewUserRepo(database, cfg.SecretKey)).Notify,
+	}
+	importer.Saved = func(ctx context.Context, before *model.Product, after model.Product) {
+		hooks.Written(ctx, "cmd/import", "", before, after)
+	}
+	report, err := importer.Import(context.Background(), input, catalog.ImportOptions{
+		Format:   f,
+		DryRun:   *dryRun,
+		ImageDir: *images,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
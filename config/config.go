@@ -0,0 +1,265 @@
+// Package config loads the service settings. Values are layered, later
+// sources winning: built-in defaults, an optional YAML or TOML file, the
+// environment (including a .env file) and command-line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image-server/i18n"
+	"image-server/logging"
+	"image-server/money"
+	"image-server/tracing"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Port      string
+	MongoURI  string
+	DBName    string
+	SecretKey string
+	// AdminEmails are promoted to admin at startup, so a fresh database can
+	// get its first admin.
+	AdminEmails []string
+
+	Currency         string
+	DefaultLocale    string
+	SupportedLocales []string
+
+	ShippingConfig    string
+	TaxConfig         string
+	ExchangeRatesFile string
+	ImportImageDir    string
+	PublicBaseURL     string
+
+	SoftDeleteRetention time.Duration
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	HealthCheckTimeout time.Duration
+
+	LogLevel string
+
+	TraceExporter string
+	TraceEndpoint string
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() *Config {
+	return &Config{
+		Port:                "8080",
+		Currency:            money.DefaultCurrency,
+		DefaultLocale:       i18n.DefaultLocale,
+		SupportedLocales:    append([]string(nil), i18n.SupportedLocales...),
+		SoftDeleteRetention: 30 * 24 * time.Hour,
+		ReadTimeout:         15 * time.Second,
+		WriteTimeout:        60 * time.Second,
+		IdleTimeout:         2 * time.Minute,
+		ShutdownTimeout:     30 * time.Second,
+		HealthCheckTimeout:  2 * time.Second,
+		LogLevel:            "info",
+		TraceExporter:       tracing.ExporterNone,
+	}
+}
+
+// setting binds one field to its file key, environment variable and flag.
+// The flag name is the key with dashes.
+type setting struct {
+	key   string
+	env   string
+	usage string
+	value interface{}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"port", "PORT", "HTTP listen port", &c.Port},
+		{"mongo_uri", "MONGOURI", "MongoDB connection URI", &c.MongoURI},
+		{"db_name", "DB_NAME", "MongoDB database name", &c.DBName},
+		{"secret_key", "SECRET_KEY", "HMAC key signing access tokens", &c.SecretKey},
+		{"admin_emails", "ADMIN_EMAILS", "comma-separated emails of users promoted to admin at startup", &c.AdminEmails},
+		{"currency", "CURRENCY", "default ISO 4217 currency", &c.Currency},
+		{"default_locale", "DEFAULT_LOCALE", "locale of untranslated content", &c.DefaultLocale},
+		{"supported_locales", "SUPPORTED_LOCALES", "comma-separated locales served", &c.SupportedLocales},
+		{"shipping_config", "SHIPPING_CONFIG", "shipping zones JSON file", &c.ShippingConfig},
+		{"tax_config", "TAX_CONFIG", "tax rules JSON file", &c.TaxConfig},
+		{"exchange_rates_file", "EXCHANGE_RATES_FILE", "exchange rate table loaded at startup", &c.ExchangeRatesFile},
+		{"import_image_dir", "IMPORT_IMAGE_DIR", "directory product imports read images from", &c.ImportImageDir},
+		{"public_base_url", "PUBLIC_BASE_URL", "public origin used in feed links", &c.PublicBaseURL},
+		{"soft_delete_retention", "SOFT_DELETE_RETENTION", "how long deleted records are kept", &c.SoftDeleteRetention},
+		{"read_timeout", "READ_TIMEOUT", "maximum time to read a request", &c.ReadTimeout},
+		{"write_timeout", "WRITE_TIMEOUT", "maximum time to write a response", &c.WriteTimeout},
+		{"idle_timeout", "IDLE_TIMEOUT", "how long idle keep-alive connections stay open", &c.IdleTimeout},
+		{"shutdown_timeout", "SHUTDOWN_TIMEOUT", "how long shutdown waits for in-flight requests", &c.ShutdownTimeout},
+		{"health_check_timeout", "HEALTH_CHECK_TIMEOUT", "time limit of each readiness check", &c.HealthCheckTimeout},
+		{"log_level", "LOG_LEVEL", "minimum log level: debug, info, warn or error", &c.LogLevel},
+		{"trace_exporter", "TRACE_EXPORTER", "where spans go: none, stdout or otlp", &c.TraceExporter},
+		{"trace_endpoint", "TRACE_ENDPOINT", "OTLP/HTTP traces URL; defaults to $OTEL_EXPORTER_OTLP_ENDPOINT", &c.TraceEndpoint},
+	}
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+func (s setting) set(raw string) error {
+	switch v := s.value.(type) {
+	case *string:
+		*v = raw
+	case *[]string:
+		*v = nil
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*v = append(*v, item)
+			}
+		}
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.key, err)
+		}
+		*v = d
+	}
+	return nil
+}
+
+// Load reads the configuration. It registers one flag per setting, plus
+// -config naming the optional file (also CONFIG_FILE), on fs and parses args
+// with it, so commands can add their own flags to the same set first.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	c := Default()
+	settings := c.settings()
+	flagValues := map[string]*string{}
+	for _, s := range settings {
+		flagValues[s.key] = fs.String(s.flagName(), "", s.usage+" ($"+s.env+")")
+	}
+	file := fs.String("config", "", "YAML or TOML settings file ($CONFIG_FILE)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	// A missing .env is fine: the environment may be set another way.
+	_ = godotenv.Load()
+	if *file == "" {
+		*file = os.Getenv("CONFIG_FILE")
+	}
+	if *file != "" {
+		if err := c.loadFile(*file); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		if raw, ok := os.LookupEnv(s.env); ok && raw != "" {
+			if err := s.set(raw); err != nil {
+				return nil, fmt.Errorf("config: $%s: %w", s.env, err)
+			}
+		}
+		if explicit[s.flagName()] {
+			if err := s.set(*flagValues[s.key]); err != nil {
+				return nil, fmt.Errorf("config: -%s: %w", s.flagName(), err)
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config: %s: unsupported file type, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	known := map[string]setting{}
+	for _, s := range c.settings() {
+		known[s.key] = s
+	}
+	for key, value := range values {
+		s, ok := known[key]
+		if !ok {
+			return fmt.Errorf("config: %s: unknown setting %q", path, key)
+		}
+		raw := fmt.Sprint(value)
+		if list, ok := value.([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			raw = strings.Join(items, ",")
+		}
+		if err := s.set(raw); err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ValidateDatabase checks the settings needed to reach MongoDB, which is all
+// the command-line tools use.
+func (c *Config) ValidateDatabase() error {
+	var errs []error
+	if c.MongoURI == "" {
+		errs = append(errs, errors.New("mongo_uri is required (set $MONGOURI or -mongo-uri)"))
+	}
+	if c.DBName == "" {
+		errs = append(errs, errors.New("db_name is required (set $DB_NAME or -db-name)"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks everything the server needs and reports every problem at
+// once.
+func (c *Config) Validate() error {
+	errs := []error{c.ValidateDatabase()}
+	if c.SecretKey == "" {
+		errs = append(errs, errors.New("secret_key is required (set $SECRET_KEY or -secret-key)"))
+	}
+	if c.Port == "" {
+		errs = append(errs, errors.New("port must not be empty"))
+	}
+	if !money.ValidCurrency(c.Currency) {
+		errs = append(errs, fmt.Errorf("currency %q is not an ISO 4217 code", c.Currency))
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level %q must be debug, info, warn or error", c.LogLevel))
+	}
+	if !tracing.ValidExporter(c.TraceExporter) {
+		errs = append(errs, fmt.Errorf("trace_exporter %q must be none, stdout or otlp", c.TraceExporter))
+	}
+	for _, s := range c.settings() {
+		if d, ok := s.value.(*time.Duration); ok && *d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", s.key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Apply sets the package-level defaults other packages read.
+func (c *Config) Apply() {
+	money.DefaultCurrency = c.Currency
+	i18n.DefaultLocale = c.DefaultLocale
+	i18n.SupportedLocales = c.SupportedLocales
+}
@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"fmt"
+	"image-server/apierr"
+	"image-server/logging"
+	"image-server/model"
+	"image-server/reponsitory"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addressRequiredFields lists the fields a country needs on top of the ones
+// every address must have (full name, line1, city and country).
+var addressRequiredFields = map[string][]string{
+	"VN": {"region"},
+	"US": {"region", "postal_code"},
+	"CA": {"region", "postal_code"},
+	"AU": {"region", "postal_code"},
+	"JP": {"region", "postal_code"},
+	"GB": {"postal_code"},
+	"DE": {"postal_code"},
+	"FR": {"postal_code"},
+	"SG": {"postal_code"},
+}
+
+func validateAddress(address model.Address) map[string]string {
+	values := map[string]string{
+		"full_name":   address.FullName,
+		"line1":       address.Line1,
+		"city":        address.City,
+		"region":      address.Region,
+		"postal_code": address.PostalCode,
+		"country":     address.Country,
+	}
+	required := append([]string{"full_name", "line1", "city", "country"}, addressRequiredFields[address.Country]...)
+	errs := map[string]string{}
+	for _, field := range required {
+		if values[field] == "" {
+			errs[field] = "is required"
+		}
+	}
+	if address.Country != "" && len(address.Country) != 2 {
+		errs["country"] = "must be an ISO 3166-1 alpha-2 code"
+	}
+	return errs
+}
+
+type AddressController struct {
+	AddressRepo reponsitory.AddressRepo
+	UserRepo    reponsitory.UserRepo
+}
+
+func NewAddressController(AddressRepo reponsitory.AddressRepo, UserRepo reponsitory.UserRepo) *AddressController {
+	return &AddressController{AddressRepo: AddressRepo, UserRepo: UserRepo}
+}
+
+func (a *AddressController) currentUser(c *gin.Context) (model.User, bool) {
+	user, err := a.UserRepo.FindByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		apierr.Write(c, unknownUser(err))
+		return model.User{}, false
+	}
+	return user, true
+}
+
+// applyAddressRequest copies the request onto the address. Text fields are
+// always replaced so that PUT can clear optional values like line2.
+func applyAddressRequest(address *model.Address, req model.AddressRequest) {
+	address.Label = strings.TrimSpace(req.Label)
+	address.FullName = strings.TrimSpace(req.FullName)
+	address.Phone = strings.TrimSpace(req.Phone)
+	address.Line1 = strings.TrimSpace(req.Line1)
+	address.Line2 = strings.TrimSpace(req.Line2)
+	address.City = strings.TrimSpace(req.City)
+	address.Region = strings.TrimSpace(req.Region)
+	address.PostalCode = strings.TrimSpace(req.PostalCode)
+	address.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+	if req.IsDefaultShipping != nil {
+		address.IsDefaultShipping = *req.IsDefaultShipping
+	}
+	if req.IsDefaultBilling != nil {
+		address.IsDefaultBilling = *req.IsDefaultBilling
+	}
+}
+
+func (a *AddressController) GetAddresses(c *gin.Context) {
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	addresses, err := a.AddressRepo.GetByUser(c.Request.Context(), user.ID)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"addresses": addresses,
+	})
+}
+
+func (a *AddressController) GetAddress(c *gin.Context) {
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	address, err := a.AddressRepo.FindForUser(c.Request.Context(), user.ID, c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Address not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"address": address,
+	})
+}
+
+func (a *AddressController) CreateAddress(c *gin.Context) {
+	var req model.AddressRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	address := model.Address{UserID: user.ID}
+	applyAddressRequest(&address, req)
+	if errs := validateAddress(address); len(errs) > 0 {
+		apierr.Write(c, apierr.Validation("Invalid address", errs))
+		return
+	}
+	// The first saved address becomes the default for both purposes.
+	count, err := a.AddressRepo.Count(c.Request.Context(), user.ID)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	if count == 0 {
+		address.IsDefaultShipping = true
+		address.IsDefaultBilling = true
+	}
+	address.Created_At = time.Now()
+	address.Updated_At = time.Now()
+	address, err = a.AddressRepo.Create(c.Request.Context(), address)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"address": address,
+	})
+}
+
+func (a *AddressController) UpdateAddress(c *gin.Context) {
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	address, err := a.AddressRepo.FindForUser(c.Request.Context(), user.ID, c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Address not found"))
+		return
+	}
+	var req model.AddressRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	applyAddressRequest(&address, req)
+	if errs := validateAddress(address); len(errs) > 0 {
+		apierr.Write(c, apierr.Validation("Invalid address", errs))
+		return
+	}
+	address.Updated_At = time.Now()
+	address, err = a.AddressRepo.Update(c.Request.Context(), address)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"address": address,
+	})
+}
+
+func (a *AddressController) DeleteAddress(c *gin.Context) {
+	user, ok := a.currentUser(c)
+	if !ok {
+		return
+	}
+	address, err := a.AddressRepo.FindForUser(c.Request.Context(), user.ID, c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Address not found"))
+		return
+	}
+	if err := a.AddressRepo.Delete(c.Request.Context(), user.ID, address.ID.Hex()); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Address not found"))
+		return
+	}
+	if address.IsDefaultShipping || address.IsDefaultBilling {
+		a.promoteDefault(c, address)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": fmt.Sprintf("Address %s deleted", address.ID.Hex()),
+	})
+}
+
+// promoteDefault hands the default flags of a deleted address to the oldest
+// remaining one.
+func (a *AddressController) promoteDefault(c *gin.Context, deleted model.Address) {
+	remaining, err := a.AddressRepo.GetByUser(c.Request.Context(), deleted.UserID)
+	if err != nil || len(remaining) == 0 {
+		return
+	}
+	next := remaining[0]
+	next.IsDefaultShipping = next.IsDefaultShipping || deleted.IsDefaultShipping
+	next.IsDefaultBilling = next.IsDefaultBilling || deleted.IsDefaultBilling
+	if _, err := a.AddressRepo.Update(c.Request.Context(), next); err != nil {
+		logging.FromContext(c.Request.Context()).Error("promote default address", "address_id", next.ID.Hex(), "error", err)
+	}
+}
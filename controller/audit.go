@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/audit"
+	"image-server/model"
+	"image-server/reponsitory"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit stores who changed what on an entity, as audit.Record does,
+// with the caller and request ID of c.
+func recordAudit(c *gin.Context, repo reponsitory.AuditRepo, entity, entityID, action string, before, after interface{}) {
+	audit.Record(c.Request.Context(), repo, auditEntry(c, entity, entityID, action), before, after)
+}
+
+func recordAuditChanges(c *gin.Context, repo reponsitory.AuditRepo, entity, entityID, action string, changes []model.FieldChange) {
+	entry := auditEntry(c, entity, entityID, action)
+	entry.Changes = changes
+	audit.RecordChanges(c.Request.Context(), repo, entry)
+}
+
+func auditEntry(c *gin.Context, entity, entityID, action string) model.AuditEntry {
+	return model.AuditEntry{
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Actor:     c.GetString("email"),
+		RequestID: c.GetString("request_id"),
+	}
+}
+
+type AuditController struct {
+	AuditRepo reponsitory.AuditRepo
+}
+
+func NewAuditController(AuditRepo reponsitory.AuditRepo) *AuditController {
+	return &AuditController{AuditRepo: AuditRepo}
+}
+
+// GetAuditLog lists audit entries filtered by entity, entity_id, actor,
+// action and an RFC 3339 from/to range, newest first (limit defaults to 100).
+func (a *AuditController) GetAuditLog(c *gin.Context) {
+	filter := model.AuditFilter{
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		Limit:    100,
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit <= 0 || limit > 1000 {
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+	for _, bound := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if v := c.Query(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid "+bound.name))
+				return
+			}
+			*bound.value = t
+		}
+	}
+	entries, err := a.AuditRepo.Find(c.Request.Context(), filter)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+	})
+}
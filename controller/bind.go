@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image-server/apierr"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+var setupValidator sync.Once
+
+// validatorEngine configures gin's validator on first use: errors are keyed
+// by the json (or form) name clients send, and "notblank" rejects
+// whitespace-only strings.
+func validatorEngine() {
+	setupValidator.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(fieldName)
+		v.RegisterValidation("notblank", validators.NotBlank)
+	})
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// bindJSON decodes and validates the JSON body into obj. It writes the
+// error response itself and reports false on failure.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	validatorEngine()
+	if err := c.ShouldBindJSON(obj); err != nil {
+		apierr.Write(c, bindError(err))
+		return false
+	}
+	return true
+}
+
+// bindForm decodes and validates a form or multipart body into obj,
+// including uploaded files. It writes the error response itself and reports
+// false on failure.
+func bindForm(c *gin.Context, obj interface{}) bool {
+	validatorEngine()
+	if err := c.ShouldBind(obj); err != nil {
+		apierr.Write(c, bindError(err))
+		return false
+	}
+	return true
+}
+
+// validate checks obj against its binding tags, for values that were not
+// decoded by gin such as merge-patched documents.
+func validate(obj interface{}) error {
+	validatorEngine()
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return bindError(err)
+	}
+	return nil
+}
+
+// bindError turns decoding and validation errors into API errors, with one
+// entry per offending field where the field is known.
+func bindError(err error) error {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		fields := make(map[string]string, len(invalid))
+		for _, fe := range invalid {
+			fields[fieldPath(fe)] = fieldMessage(fe)
+		}
+		return apierr.Validation("Invalid request", fields)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return apierr.Validation("Invalid request", map[string]string{
+			typeErr.Field: "must be " + jsonType(typeErr.Type),
+		})
+	}
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return apierr.New(apierr.CodeBadRequest, fmt.Sprintf("Invalid number %q", numErr.Num))
+	}
+	if mapped := apierr.From(err); mapped.Code != apierr.CodeInternal {
+		return mapped
+	}
+	return apierr.New(apierr.CodeBadRequest, err.Error())
+}
+
+// jsonType names the JSON type a Go value decodes from.
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// fieldPath drops the struct name from the namespace, giving paths such as
+// "items[0].quantity".
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	var unit string
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		unit = " items"
+	}
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "mongodb":
+		return "must be a valid id"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "len":
+		return "must be exactly " + fe.Param() + unit
+	case "min", "gte":
+		return "must be at least " + fe.Param() + unit
+	case "max", "lte":
+		return "must be at most " + fe.Param() + unit
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "alpha":
+		return "must contain only letters"
+	}
+	return "is invalid"
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"image-server/apierr"
+	"image-server/model"
+	"image-server/reponsitory"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cartLine struct {
+	Product  model.Product
+	Quantity int
+}
+
+// loadCart resolves the products of a cart, rejecting unknown products and
+// non-positive quantities.
+func loadCart(ctx context.Context, productRepo reponsitory.ProductRepo, items []model.CartItem) ([]cartLine, error) {
+	if len(items) == 0 {
+		return nil, apierr.New(apierr.CodeBadRequest, "Cart is empty")
+	}
+	lines := make([]cartLine, 0, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, apierr.New(apierr.CodeBadRequest, fmt.Sprintf("Invalid quantity for product %s", item.ProductID))
+		}
+		product, err := productRepo.FindByID(ctx, item.ProductID)
+		if apierr.From(err).Code == apierr.CodeNotFound {
+			return nil, apierr.New(apierr.CodeBadRequest, fmt.Sprintf("Product %s not found", item.ProductID))
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, cartLine{Product: product, Quantity: item.Quantity})
+	}
+	return lines, nil
+}
+
+type cartDestination struct {
+	Country    string
+	Region     string
+	PostalCode string
+}
+
+// resolveDestination picks the destination of a quote request, loading the
+// saved address of the current user when address_id is set. It writes the
+// error response itself and reports false on failure.
+func resolveDestination(c *gin.Context, userRepo reponsitory.UserRepo, addressRepo reponsitory.AddressRepo, req model.CartQuoteRequest) (cartDestination, bool) {
+	destination := cartDestination{
+		Country:    strings.ToUpper(req.Country),
+		Region:     req.Region,
+		PostalCode: req.PostalCode,
+	}
+	if req.AddressID != "" {
+		user, err := userRepo.FindByEmail(c.Request.Context(), c.GetString("email"))
+		if err != nil {
+			apierr.Write(c, unknownUser(err))
+			return cartDestination{}, false
+		}
+		address, err := addressRepo.FindForUser(c.Request.Context(), user.ID, req.AddressID)
+		if err != nil {
+			apierr.Write(c, apierr.NotFound(err, "Address not found"))
+			return cartDestination{}, false
+		}
+		destination = cartDestination{
+			Country:    address.Country,
+			Region:     address.Region,
+			PostalCode: address.PostalCode,
+		}
+	}
+	if destination.Country == "" {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Destination country is required"))
+		return cartDestination{}, false
+	}
+	return destination, true
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/model"
+	"image-server/reponsitory"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryController struct {
+	CategoryRepo reponsitory.CategoryRepo
+}
+
+func NewCategoryController(CategoryRepo reponsitory.CategoryRepo) *CategoryController {
+	return &CategoryController{CategoryRepo: CategoryRepo}
+}
+
+func (cc *CategoryController) GetAllCategory(c *gin.Context) {
+	items, err := cc.CategoryRepo.GetAll(c.Request.Context())
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	locale := requestLocale(c)
+	categories := make([]model.CategoryResponse, 0, len(items))
+	for _, item := range items {
+		categories = append(categories, item.Localize(locale))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+		"locale":     locale,
+	})
+}
+
+func (cc *CategoryController) GetCategory(c *gin.Context) {
+	category, err := cc.CategoryRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Category not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"category": category.Localize(requestLocale(c)),
+	})
+}
+
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	var req model.CategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	category := model.Category{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Created_At:  time.Now(),
+		Updated_At:  time.Now(),
+	}
+	category, err := cc.CategoryRepo.Create(c.Request.Context(), category)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+	})
+}
+
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	category, err := cc.CategoryRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Category not found"))
+		return
+	}
+	var req model.CategoryUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		category.Name = name
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		category.Description = description
+	}
+	category.Updated_At = time.Now()
+	category, err = cc.CategoryRepo.Update(c.Request.Context(), category)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+	})
+}
+
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	if err := cc.CategoryRepo.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Category not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Category deleted",
+	})
+}
+
+func (cc *CategoryController) SetCategoryTranslation(c *gin.Context) {
+	locale, translation, ok := bindTranslation(c)
+	if !ok {
+		return
+	}
+	if err := cc.CategoryRepo.SetTranslation(c.Request.Context(), c.Param("id"), locale, translation); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Category not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"locale":      locale,
+		"translation": translation,
+	})
+}
+
+func (cc *CategoryController) DeleteCategoryTranslation(c *gin.Context) {
+	locale, ok := translationLocale(c)
+	if !ok {
+		return
+	}
+	if err := cc.CategoryRepo.DeleteTranslation(c.Request.Context(), c.Param("id"), locale); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Category not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Translation deleted",
+	})
+}
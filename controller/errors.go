@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"image-server/apierr"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// unknownUser maps the error of looking up the user named by the token: a
+// user deleted since the token was issued is no longer authenticated.
+func unknownUser(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return apierr.New(apierr.CodeUnauthenticated, "User not found")
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image-server/apierr"
+	"image-server/model"
+	"image-server/money"
+	"image-server/reponsitory"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ExchangeRateController struct {
+	ExchangeRateRepo reponsitory.ExchangeRateRepo
+}
+
+func NewExchangeRateController(ExchangeRateRepo reponsitory.ExchangeRateRepo) *ExchangeRateController {
+	return &ExchangeRateController{ExchangeRateRepo: ExchangeRateRepo}
+}
+
+func normalizeRates(req model.ExchangeRatesRequest) (model.ExchangeRates, error) {
+	rates := model.ExchangeRates{
+		Base:  strings.ToUpper(req.Base),
+		Rates: make(map[string]float64, len(req.Rates)),
+	}
+	if !money.ValidCurrency(rates.Base) {
+		return model.ExchangeRates{}, fmt.Errorf("Invalid base currency %q", req.Base)
+	}
+	for currency, rate := range req.Rates {
+		currency = strings.ToUpper(currency)
+		if !money.ValidCurrency(currency) || rate <= 0 {
+			return model.ExchangeRates{}, fmt.Errorf("Invalid rate for %q", currency)
+		}
+		rates.Rates[currency] = rate
+	}
+	return rates, nil
+}
+
+// save stores a new snapshot unless it is identical to the latest one.
+func (e *ExchangeRateController) save(ctx context.Context, rates model.ExchangeRates) (model.ExchangeRates, error) {
+	latest, err := e.ExchangeRateRepo.Latest(ctx)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return model.ExchangeRates{}, err
+	}
+	if err == nil && latest.Base == rates.Base && reflect.DeepEqual(latest.Rates, rates.Rates) {
+		return latest, nil
+	}
+	rates.Created_At = time.Now()
+	return e.ExchangeRateRepo.Create(ctx, rates)
+}
+
+// LoadFile imports a JSON rate table such as
+// {"base":"VND","rates":{"USD":0.000039,"EUR":0.000036}} at startup.
+func (e *ExchangeRateController) LoadFile(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var req model.ExchangeRatesRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	rates, err := normalizeRates(req)
+	if err != nil {
+		return err
+	}
+	rates.Source = "file:" + path
+	_, err = e.save(ctx, rates)
+	return err
+}
+
+func (e *ExchangeRateController) GetExchangeRates(c *gin.Context) {
+	rates, err := e.ExchangeRateRepo.Latest(c.Request.Context())
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "No exchange rates configured"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"exchange_rates": rates,
+	})
+}
+
+func (e *ExchangeRateController) UpdateExchangeRates(c *gin.Context) {
+	var req model.ExchangeRatesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	rates, err := normalizeRates(req)
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return
+	}
+	rates.Source = "admin:" + c.GetString("email")
+	rates, err = e.save(c.Request.Context(), rates)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"exchange_rates": rates,
+	})
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"image-server/migration"
+	"image-server/storage"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+type HealthController struct {
+	DB *mongo.Database
+	// Timeout bounds each readiness check.
+	Timeout time.Duration
+}
+
+func NewHealthController(db *mongo.Database, timeout time.Duration) *HealthController {
+	return &HealthController{DB: db, Timeout: timeout}
+}
+
+// Healthz reports that the process is up and serving requests.
+func (h *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz runs every dependency check concurrently and answers 503 unless
+// all of them pass.
+func (h *HealthController) Readyz(c *gin.Context) {
+	checks := map[string]func(ctx context.Context) error{
+		"mongo":      h.checkMongo,
+		"gridfs":     h.checkGridFS,
+		"migrations": h.checkMigrations,
+	}
+	results := make(map[string]CheckResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), h.Timeout)
+			defer cancel()
+			start := time.Now()
+			result := CheckResult{Status: "ok"}
+			if err := check(ctx); err != nil {
+				result.Status, result.Error = "fail", err.Error()
+			}
+			result.DurationMS = time.Since(start).Milliseconds()
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	status, code := "ok", http.StatusOK
+	for _, result := range results {
+		if result.Status != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": results,
+	})
+}
+
+func (h *HealthController) checkMongo(ctx context.Context) error {
+	return h.DB.Client().Ping(ctx, readpref.Primary())
+}
+
+func (h *HealthController) checkGridFS(ctx context.Context) error {
+	bucket, err := gridfs.NewBucket(h.DB, options.GridFSBucket().SetName(storage.ProductBucket))
+	if err != nil {
+		return err
+	}
+	cursor, err := bucket.FindContext(ctx, bson.M{}, options.GridFSFind().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+func (h *HealthController) checkMigrations(ctx context.Context) error {
+	pending, err := migration.Pending(ctx, h.DB, migration.All)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
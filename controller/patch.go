@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"fmt"
+	"image-server/apierr"
+	"image-server/mergepatch"
+	"io"
+	"mime"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxPatchBytes = 1 << 20
+
+// readMergePatch reads a JSON Merge Patch (or plain JSON object) body. The
+// "version" member is removed from the patch and returned separately. It
+// writes an error response and reports false on bad input.
+func readMergePatch(c *gin.Context) (map[string]interface{}, string, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != mergepatch.ContentType && mediaType != "application/json" {
+		apierr.Write(c, apierr.New(apierr.CodeUnsupportedMediaType, "Send application/merge-patch+json or application/json"))
+		return nil, "", false
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPatchBytes+1))
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return nil, "", false
+	}
+	if len(body) > maxPatchBytes {
+		apierr.Write(c, apierr.New(apierr.CodePayloadTooLarge, "Patch too large"))
+		return nil, "", false
+	}
+	decoded, err := mergepatch.Decode(body)
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid JSON: "+err.Error()))
+		return nil, "", false
+	}
+	patch, ok := decoded.(map[string]interface{})
+	if !ok {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Patch must be a JSON object"))
+		return nil, "", false
+	}
+	version := ""
+	if v, ok := patch["version"]; ok {
+		if v != nil {
+			version = fmt.Sprint(v)
+		}
+		delete(patch, "version")
+	}
+	return patch, version, true
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/logging"
+	"image-server/model"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordPriceChange adds a price history entry when the regular price of
+// product differs from previous.
+func (p *ProductController) recordPriceChange(c *gin.Context, previous model.Product, product model.Product, reason string) {
+	if p.PriceRepo == nil || previous.Price == product.Price {
+		return
+	}
+	err := p.PriceRepo.RecordChange(c.Request.Context(), model.PriceHistory{
+		ProductID:  product.ID,
+		Previous:   previous.Price,
+		Price:      product.Price,
+		Reason:     reason,
+		Actor:      c.GetString("email"),
+		Changed_At: time.Now(),
+	})
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("record price history", "product_id", product.ID.Hex(), "error", err)
+	}
+}
+
+func (p *ProductController) GetPriceHistory(c *gin.Context) {
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	history, err := p.PriceRepo.History(c.Request.Context(), product.ID)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+	})
+}
+
+func (p *ProductController) GetPriceSchedules(c *gin.Context) {
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	schedules, err := p.PriceRepo.Schedules(c.Request.Context(), product.ID)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+	})
+}
+
+// SchedulePrice schedules a regular price change at starts_at, or a sale
+// price between starts_at (default now) and ends_at.
+func (p *ProductController) SchedulePrice(c *gin.Context) {
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	var req model.PriceScheduleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	now := time.Now()
+	if req.Price.Currency != product.Price.Currency {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Price must be in "+product.Price.Currency))
+		return
+	}
+	if req.Price.IsNegative() || req.Price.IsZero() {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Price must be positive"))
+		return
+	}
+	switch req.Kind {
+	case model.PriceChangeKind:
+		if !req.StartsAt.After(now) {
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, "starts_at must be in the future"))
+			return
+		}
+		req.EndsAt = time.Time{}
+	case model.SaleKind:
+		if req.StartsAt.IsZero() {
+			req.StartsAt = now
+		}
+		if !req.EndsAt.After(req.StartsAt) || !req.EndsAt.After(now) {
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, "ends_at must be after starts_at and in the future"))
+			return
+		}
+		if cmp, _ := req.Price.Cmp(product.Price); cmp >= 0 {
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Sale price must be lower than the regular price"))
+			return
+		}
+	default:
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "kind must be price or sale"))
+		return
+	}
+	schedule, err := p.PriceRepo.CreateSchedule(c.Request.Context(), model.PriceSchedule{
+		ProductID:  product.ID,
+		Kind:       req.Kind,
+		Price:      req.Price,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Status:     model.ScheduleScheduled,
+		Actor:      c.GetString("email"),
+		Created_At: now,
+	})
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"schedule": schedule,
+	})
+}
+
+// CancelPriceSchedule cancels a pending change, or ends a running sale now.
+func (p *ProductController) CancelPriceSchedule(c *gin.Context) {
+	schedule, err := p.PriceRepo.CancelSchedule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Pending price schedule not found"))
+		return
+	}
+	if schedule.Status == model.ScheduleActive {
+		product, err := p.ProductRepo.FindByID(c.Request.Context(), schedule.ProductID.Hex())
+		if err == nil && product.Sale != nil && product.Sale.ScheduleID == schedule.ID {
+			if err := p.ProductRepo.SetSale(c.Request.Context(), product.ID, nil); err != nil {
+				apierr.Write(c, err)
+				return
+			}
+			ended := product
+			ended.Price = schedule.Price
+			p.recordPriceChange(c, ended, product, model.PriceReasonSaleEnd)
+		}
+	}
+	schedule.Status = model.ScheduleCancelled
+	c.JSON(http.StatusOK, gin.H{
+		"schedule": schedule,
+	})
+}
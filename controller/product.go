@@ -1,14 +1,22 @@
 package controller
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"image-server/apierr"
+	"image-server/catalog"
+	"image-server/logging"
+	"image-server/mergepatch"
+	"image-server/metrics"
 	"image-server/model"
+	"image-server/money"
 	"image-server/reponsitory"
+	"image-server/storage"
+	"image-server/tax"
 	"io"
-	"log"
 	"net/http"
-	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -16,101 +24,282 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/gridfs"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductController struct {
 	ProductRepo reponsitory.ProductRepo
 	DB          *mongo.Database
+	// OnBackInStock is called when an update raises quantity from zero.
+	OnBackInStock func(ctx context.Context, product model.Product)
+	// ExchangeRateRepo converts prices when a listing asks for a currency.
+	ExchangeRateRepo reponsitory.ExchangeRateRepo
+	AuditRepo        reponsitory.AuditRepo
+	PriceRepo        reponsitory.PriceRepo
+	// ImportImageDir is where imports resolve the "image" column.
+	ImportImageDir string
+	// PublicBaseURL is the origin used for absolute links in exports.
+	PublicBaseURL string
 }
 
 func NewProductController(ProductRepo reponsitory.ProductRepo, db *mongo.Database) *ProductController {
 	return &ProductController{ProductRepo: ProductRepo, DB: db}
 }
 
+// presentProducts localises products and, when ?currency= is given,
+// converts their prices. It returns the extra response fields describing the
+// locale and rates used, or writes an error response and reports false.
+func (p *ProductController) presentProducts(c *gin.Context, products []model.ProductResponse) (gin.H, bool) {
+	locale := requestLocale(c)
+	for i := range products {
+		products[i].Localize(locale)
+	}
+	extra := gin.H{"locale": locale}
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" {
+		return extra, true
+	}
+	if !money.ValidCurrency(currency) {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid currency"))
+		return nil, false
+	}
+	// Products may all carry overrides, so a missing rate table only fails
+	// once a conversion is actually needed.
+	rates, err := p.ExchangeRateRepo.Latest(c.Request.Context())
+	if err != nil && err != mongo.ErrNoDocuments {
+		apierr.Write(c, err)
+		return nil, false
+	}
+	for i := range products {
+		if err := products[i].ConvertTo(currency, rates.Table()); err != nil {
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+			return nil, false
+		}
+	}
+	extra["currency"] = currency
+	extra["exchange_rates"] = rates
+	return extra, true
+}
+
 func (p *ProductController) GetAllProduct(c *gin.Context) {
-	products, err := p.ProductRepo.GetAll(c)
+	products, err := p.ProductRepo.GetAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apierr.Write(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"products": products,
-	})
+	response, ok := p.presentProducts(c, products)
+	if !ok {
+		return
+	}
+	response["products"] = products
+	c.JSON(http.StatusOK, response)
 }
 
-func (p *ProductController) CreateProduct(c *gin.Context) {
-	product := model.Product{
-		ProductName: c.Request.FormValue("productname"),
-		Brand:       c.Request.FormValue("brand"),
-		Description: c.Request.FormValue("description"),
-	}
-	quantity, err := strconv.Atoi(c.Request.FormValue("quantity"))
+func (p *ProductController) GetProduct(c *gin.Context) {
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quantity"})
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	products := []model.ProductResponse{product.Response()}
+	response, ok := p.presentProducts(c, products)
+	if !ok {
 		return
 	}
-	product.Quantity = quantity
+	response["product"] = products[0]
+	c.Header("ETag", etag(product.Version))
+	c.JSON(http.StatusOK, response)
+}
 
-	price, err := strconv.ParseFloat(c.Request.FormValue("price"), 64)
+// GetProductBySlug serves a product by its slug and permanently redirects
+// former slugs to the current one.
+func (p *ProductController) GetProductBySlug(c *gin.Context) {
+	requested := c.Param("slug")
+	product, err := p.ProductRepo.FindBySlug(c.Request.Context(), requested)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price"})
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
 		return
 	}
-	product.Price = price
-	file, header, err := c.Request.FormFile("image2")
+	if product.Slug != requested {
+		target := "/api/product/slug/" + product.Slug
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, target)
+		return
+	}
+	products := []model.ProductResponse{product.Response()}
+	response, ok := p.presentProducts(c, products)
+	if !ok {
+		return
+	}
+	response["product"] = products[0]
+	c.Header("ETag", etag(product.Version))
+	c.JSON(http.StatusOK, response)
+}
+
+func (p *ProductController) SetProductTranslation(c *gin.Context) {
+	locale, translation, ok := bindTranslation(c)
+	if !ok {
+		return
+	}
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image upload failed"})
-		log.Print(err)
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
 		return
 	}
-	product.Created_At = time.Now()
-	product.Updated_At = time.Now()
-	defer file.Close()
-	//Create GridFS bucket
+	if err := p.ProductRepo.SetTranslation(c.Request.Context(), c.Param("id"), locale, translation); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	change := model.FieldChange{Field: "translations." + locale, After: translation}
+	if previous, ok := product.Translations[locale]; ok {
+		change.Before = previous
+	}
+	recordAuditChanges(c, p.AuditRepo, "product", product.ID.Hex(), model.AuditUpdate, []model.FieldChange{change})
+	c.JSON(http.StatusOK, gin.H{
+		"locale":      locale,
+		"translation": translation,
+	})
+}
 
-	bucket, err := gridfs.NewBucket(p.DB.Client().Database(os.Getenv("DB_NAME")), options.GridFSBucket().SetName("products"))
+func (p *ProductController) DeleteProductTranslation(c *gin.Context) {
+	locale, ok := translationLocale(c)
+	if !ok {
+		return
+	}
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create GridFS bucket"})
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	if err := p.ProductRepo.DeleteTranslation(c.Request.Context(), c.Param("id"), locale); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
 		return
 	}
-	//Read image
-	buf := bytes.NewBuffer(nil)
-	if _, err := io.Copy(buf, file); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read image"})
+	if previous, ok := product.Translations[locale]; ok {
+		recordAuditChanges(c, p.AuditRepo, "product", product.ID.Hex(), model.AuditUpdate, []model.FieldChange{
+			{Field: "translations." + locale, Before: previous},
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Translation deleted",
+	})
+}
+
+// parsePriceOverrides reads the price_overrides form field, a JSON array such
+// as [{"amount":"9.99","currency":"USD"}] with at most one entry per currency.
+func parsePriceOverrides(raw string) ([]money.Money, error) {
+	var overrides []money.Money
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, fmt.Errorf("Invalid price_overrides")
+	}
+	if err := validatePriceOverrides(overrides); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// validatePriceOverrides allows at most one non-negative override per currency.
+func validatePriceOverrides(overrides []money.Money) error {
+	seen := map[string]bool{}
+	for _, override := range overrides {
+		if override.IsNegative() || seen[override.Currency] {
+			return fmt.Errorf("Invalid price override for %s", override.Currency)
+		}
+		seen[override.Currency] = true
+	}
+	return nil
+}
+
+// parsePrice reads the price form field; its errors are API errors.
+func parsePrice(amount, currency string) (money.Money, error) {
+	price, err := money.Parse(amount, currency)
+	if err != nil || price.IsNegative() {
+		return money.Money{}, apierr.Validation("Invalid request", map[string]string{
+			"price": "must be a non-negative amount",
+		})
+	}
+	return price, nil
+}
+
+// setDimensions copies the shipping weight (kg) and dimensions (cm) that
+// were sent, leaving absent ones unchanged.
+func setDimensions(product *model.Product, weight, length, width, height *float64) {
+	for _, field := range []struct {
+		value *float64
+		dest  *float64
+	}{
+		{weight, &product.Weight},
+		{length, &product.Length},
+		{width, &product.Width},
+		{height, &product.Height},
+	} {
+		if field.value != nil {
+			*field.dest = *field.value
+		}
+	}
+}
+
+func (p *ProductController) CreateProduct(c *gin.Context) {
+	var form model.ProductForm
+	if !bindForm(c, &form) {
 		return
 	}
-	//Open upload stream
-	filename := time.Now().Format(time.RFC3339) + "_" + header.Filename
-	uploadStream, err := bucket.OpenUploadStream(filename)
+	product := model.Product{
+		ProductName: strings.TrimSpace(form.ProductName),
+		SKU:         strings.TrimSpace(form.SKU),
+		Brand:       form.Brand,
+		Description: form.Description,
+		TaxClass:    form.TaxClass,
+		Quantity:    *form.Quantity,
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = tax.ClassStandard
+	}
+	if product.SKU != "" {
+		if _, err := p.ProductRepo.FindBySKU(c.Request.Context(), product.SKU); err == nil {
+			apierr.Write(c, errSKUExists)
+			return
+		}
+	}
+	price, err := parsePrice(form.Price, form.Currency)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not open upload stream"})
+		apierr.Write(c, err)
 		return
 	}
-	defer uploadStream.Close()
-	//Write to upload stream
-	fileSize, err := uploadStream.Write(buf.Bytes())
+	product.Price = price
+	if form.PriceOverrides != "" {
+		overrides, err := parsePriceOverrides(form.PriceOverrides)
+		if err != nil {
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+			return
+		}
+		product.PriceOverrides = overrides
+	}
+	setDimensions(&product, form.Weight, form.Length, form.Width, form.Height)
+	header := form.Image
+	file, err := header.Open()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not write to upload stream"})
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Image upload failed"))
 		return
 	}
-
-	// Save the file ID to the user model
-	fileId, err := json.Marshal(uploadStream.FileID)
+	product.Created_At = time.Now()
+	product.Updated_At = time.Now()
+	defer file.Close()
+	fileID, fileSize, err := storage.UploadImage(c.Request.Context(), p.DB, storage.ProductBucket, header.Filename, file)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not marshal file ID"})
+		apierr.Write(c, err)
 		return
 	}
-	product.ProductImage_URL = strings.Trim(string(fileId), `"`)
+	product.ProductImage_URL = fileID
 	// Insert the user into the database
 	products, err := p.ProductRepo.Create(c.Request.Context(), product)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not insert user"})
+		apierr.Write(c, err)
 		return
 	}
+	p.productWritten(c, nil, products)
+	metrics.ProductsCreated.Inc()
 	c.JSON(http.StatusOK, gin.H{
 		"fileId":   product.ProductImage_URL,
 		"fileSize": fileSize,
@@ -122,128 +311,367 @@ func (p *ProductController) ServeImageProduct(c *gin.Context) {
 	imageId := strings.TrimPrefix(c.Request.URL.Path, "/image2/")
 	objID, err := primitive.ObjectIDFromHex(imageId)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		apierr.Write(c, apierr.New(apierr.CodeNotFound, "Image not found"))
 		return
 	}
 
-	bucket, _ := gridfs.NewBucket(p.DB.Client().Database(os.Getenv("DB_NAME")), options.GridFSBucket().SetName("products"))
-
-	var buf bytes.Buffer
-	_, err = bucket.DownloadToStream(objID, &buf)
+	image, err := storage.DownloadImage(c.Request.Context(), p.DB, storage.ProductBucket, objID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not download image"})
+		apierr.Write(c, apierr.NotFound(err, "Image not found"))
 		return
 	}
 
-	contentType := http.DetectContentType(buf.Bytes())
+	contentType := http.DetectContentType(image)
 	c.Writer.Header().Add("Content-Type", contentType)
-	c.Writer.Header().Add("Content-Length", strconv.Itoa(len(buf.Bytes())))
-	c.Writer.Write(buf.Bytes())
+	c.Writer.Header().Add("Content-Length", strconv.Itoa(len(image)))
+	c.Writer.Write(image)
+	metrics.GridFSBytesServed.WithLabelValues(storage.ProductBucket).Add(float64(len(image)))
 }
 
 func (p *ProductController) UpdateProduct(c *gin.Context) {
 	productid := c.Param("id")
 	product, err := p.ProductRepo.FindByID(c.Request.Context(), productid)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	var form model.ProductUpdateForm
+	if !bindForm(c, &form) {
+		return
+	}
+	version, ok := expectedVersion(c, product.Version, form.Version)
+	if !ok {
 		return
 	}
-	if productname := c.PostForm("productname"); productname != "" {
+	if version != product.Version {
+		p.versionConflict(c, product)
+		return
+	}
+	before := product
+	if productname := strings.TrimSpace(form.ProductName); productname != "" {
 		product.ProductName = productname
 	}
-	if brand := c.PostForm("brand"); brand != "" {
-		product.Brand = brand
+	if sku := strings.TrimSpace(form.SKU); sku != "" && sku != product.SKU {
+		if _, err := p.ProductRepo.FindBySKU(c.Request.Context(), sku); err == nil {
+			apierr.Write(c, errSKUExists)
+			return
+		}
+		product.SKU = sku
+	}
+	if form.Brand != "" {
+		product.Brand = form.Brand
+	}
+	if form.Quantity != nil {
+		product.Quantity = *form.Quantity
 	}
-	if quantityStr := c.PostForm("quantity"); quantityStr != "" {
-		quantity, err := strconv.Atoi(quantityStr)
+	if form.Price != "" {
+		currency := form.Currency
+		if currency == "" {
+			currency = product.Price.Currency
+		}
+		price, err := parsePrice(form.Price, currency)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid quantity",
-			})
+			apierr.Write(c, err)
 			return
 		}
-		product.Quantity = quantity
+		product.Price = price
+	}
+	if form.Description != "" {
+		product.Description = form.Description
 	}
-	if prices := c.PostForm("price"); prices != "" {
-		price, err := strconv.ParseFloat(prices, 64)
+	if form.PriceOverrides != "" {
+		overrides, err := parsePriceOverrides(form.PriceOverrides)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid price",
-			})
+			apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
 			return
 		}
-		product.Price = price
+		product.PriceOverrides = overrides
 	}
-	if description := c.PostForm("description"); description != "" {
-		product.Description = description
+	if form.TaxClass != "" {
+		product.TaxClass = form.TaxClass
 	}
-	file, header, err := c.Request.FormFile("image2")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	setDimensions(&product, form.Weight, form.Length, form.Width, form.Height)
+	imageURL, ok := p.uploadProductImage(c)
+	if !ok {
+		return
 	}
-	defer file.Close()
-	bucket, err := gridfs.NewBucket(p.DB.Client().Database(os.Getenv("DB_NAME")), options.GridFSBucket().SetName("products"))
+	if imageURL != "" {
+		product.ProductImage_URL = imageURL
+	}
+	p.saveProduct(c, before, product)
+}
+
+// PatchProduct applies a JSON Merge Patch to the product, changing only the
+// fields present in the patch. The image is replaced through
+// UpdateProductImage.
+func (p *ProductController) PatchProduct(c *gin.Context) {
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create GridFS bucket"})
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
 		return
 	}
-
-	buf := bytes.NewBuffer(nil)
-	if _, err := io.Copy(buf, file); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read image"})
+	patch, bodyVersion, ok := readMergePatch(c)
+	if !ok {
+		return
+	}
+	version, ok := expectedVersion(c, product.Version, bodyVersion)
+	if !ok {
+		return
+	}
+	if version != product.Version {
+		p.versionConflict(c, product)
+		return
+	}
+	var fields model.ProductPatch
+	if err := mergepatch.Merge(product.Patchable(), patch, &fields); err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
 		return
 	}
+	if err := validate(fields); err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	before := product
+	product.ApplyPatch(fields)
+	if err := p.validatePatchedProduct(c.Request.Context(), before, product); err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	p.saveProduct(c, before, product)
+}
+
+var errSKUExists = apierr.New(apierr.CodeSKUExists, "SKU already exists")
+
+// validatePatchedProduct checks a patched product; its errors are API errors.
+func (p *ProductController) validatePatchedProduct(ctx context.Context, before, product model.Product) error {
+	if !money.ValidCurrency(product.Price.Currency) || product.Price.IsNegative() {
+		return apierr.New(apierr.CodeBadRequest, "Invalid price")
+	}
+	if err := validatePriceOverrides(product.PriceOverrides); err != nil {
+		return apierr.New(apierr.CodeBadRequest, err.Error())
+	}
+	if product.SKU != before.SKU && product.SKU != "" {
+		if _, err := p.ProductRepo.FindBySKU(ctx, product.SKU); err == nil {
+			return errSKUExists
+		}
+	}
+	return nil
+}
 
-	filename := time.Now().Format(time.RFC3339) + "_" + header.Filename
-	uploadStream, err := bucket.OpenUploadStream(filename)
+// UpdateProductImage replaces the product image with the "image2" upload.
+func (p *ProductController) UpdateProductImage(c *gin.Context) {
+	product, err := p.ProductRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not open upload stream"})
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
 		return
 	}
-	defer uploadStream.Close()
-
-	if _, err := uploadStream.Write(buf.Bytes()); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not write to upload stream"})
+	version, ok := expectedVersion(c, product.Version, c.PostForm("version"))
+	if !ok {
+		return
+	}
+	if version != product.Version {
+		p.versionConflict(c, product)
+		return
+	}
+	imageURL, ok := p.uploadProductImage(c)
+	if !ok {
+		return
+	}
+	if imageURL == "" {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "image2 is required"))
 		return
 	}
+	before := product
+	product.ProductImage_URL = imageURL
+	p.saveProduct(c, before, product)
+}
 
-	fileId, err := json.Marshal(uploadStream.FileID)
+// uploadProductImage stores the optional "image2" upload and returns its
+// file ID, or "" when no image was sent.
+func (p *ProductController) uploadProductImage(c *gin.Context) (string, bool) {
+	file, header, err := c.Request.FormFile("image2")
+	if err == http.ErrMissingFile {
+		return "", true
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not marshal file ID"})
-		return
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return "", false
 	}
-	log.Print(fileId)
-	product.ProductImage_URL = strings.Trim(string(fileId), `"`)
+	defer file.Close()
+	fileID, _, err := storage.UploadImage(c.Request.Context(), p.DB, storage.ProductBucket, header.Filename, file)
+	if err != nil {
+		apierr.Write(c, err)
+		return "", false
+	}
+	return fileID, true
+}
 
-	updatedProduct, err := p.ProductRepo.Update(c.Request.Context(), product)
+// saveProduct writes the fields changed since before and runs the update
+// side effects: audit, price history and back-in-stock notifications.
+func (p *ProductController) saveProduct(c *gin.Context, before, product model.Product) {
+	updatedProduct, err := p.ProductRepo.Patch(c.Request.Context(), before, product)
+	if err == reponsitory.ErrVersionConflict {
+		if current, err := p.ProductRepo.FindByID(c.Request.Context(), before.ID.Hex()); err == nil {
+			p.versionConflict(c, current)
+			return
+		}
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update product",
-			"err":   err.Error(),
-		})
+		apierr.Write(c, err)
 		return
 	}
+	p.productWritten(c, &before, updatedProduct)
+	p.recordPriceChange(c, before, updatedProduct, model.PriceReasonUpdate)
 
+	c.Header("ETag", etag(updatedProduct.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"product": updatedProduct,
 	})
 }
 
+// productWritten runs the side effects of a product create or update made
+// by the caller of c, including imported ones. before is nil for a create.
+func (p *ProductController) productWritten(c *gin.Context, before *model.Product, after model.Product) {
+	hooks := catalog.ProductHooks{AuditRepo: p.AuditRepo, OnBackInStock: p.OnBackInStock}
+	hooks.Written(c.Request.Context(), c.GetString("email"), c.GetString("request_id"), before, after)
+}
+
+// versionConflict answers a stale update with the current product.
+func (p *ProductController) versionConflict(c *gin.Context, current model.Product) {
+	c.Header("ETag", etag(current.Version))
+	apierr.Write(c, apierr.New(apierr.CodeVersionConflict, "Product was modified by someone else").
+		With("product", current.Response()))
+}
+
 func (p *ProductController) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid argument id",
-		})
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "invalid argument id"))
 		return
 	}
-	if err := p.ProductRepo.Delete(c, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+	before, err := p.ProductRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	if err := p.ProductRepo.Delete(c.Request.Context(), id); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	recordAudit(c, p.AuditRepo, "product", id, model.AuditDelete, before, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Product deleted",
+	})
+}
+
+// ImportProducts upserts products by SKU from an uploaded CSV or JSON lines
+// file ("file" form field, or the raw body). ?dry_run=true validates without
+// writing; images named in the "image" column are read from IMPORT_IMAGE_DIR.
+func (p *ProductController) ImportProducts(c *gin.Context) {
+	formatName := c.Query("format")
+	var body io.Reader = c.Request.Body
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		if formatName == "" {
+			formatName = strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+		}
+	}
+	if formatName == "" && strings.Contains(c.ContentType(), "csv") {
+		formatName = "csv"
+	}
+	format, err := catalog.ParseFormat(formatName)
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	importer := catalog.NewImporter(p.ProductRepo, p.DB)
+	importer.Prices = p.PriceRepo
+	importer.Saved = func(ctx context.Context, before *model.Product, after model.Product) {
+		p.productWritten(c, before, after)
+	}
+	report, err := importer.Import(c.Request.Context(), body, catalog.ImportOptions{
+		Format:   format,
+		DryRun:   dryRun,
+		ImageDir: p.ImportImageDir,
+	})
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return
+	}
+	status := http.StatusOK
+	if report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{
+		"report": report,
+	})
+}
+
+// publicBaseURL is the configured PublicBaseURL, or the origin the request
+// came in on.
+func (p *ProductController) publicBaseURL(c *gin.Context) string {
+	if p.PublicBaseURL != "" {
+		return p.PublicBaseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
 	}
+	return scheme + "://" + c.Request.Host
+}
+
+func (p *ProductController) exportProducts(c *gin.Context, format catalog.Format, filename string) {
+	exporter := catalog.NewExporter(p.ProductRepo, p.publicBaseURL(c))
+	// A full catalog can take longer to stream than the server write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", catalog.ContentType(format))
+	if filename != "" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	}
+	c.Status(http.StatusOK)
+	// Headers are already sent, so a failure can only be logged; the client
+	// sees a truncated body.
+	if err := exporter.Export(c.Request.Context(), c.Writer, format); err != nil {
+		logging.FromContext(c.Request.Context()).Error("export products", "format", string(format), "error", err)
+	}
+}
+
+// ExportProducts streams the catalog as ?format=csv, ndjson or xml.
+func (p *ProductController) ExportProducts(c *gin.Context) {
+	format, err := catalog.ParseExportFormat(c.DefaultQuery("format", "csv"))
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return
+	}
+	extension := map[catalog.Format]string{catalog.FormatCSV: "csv", catalog.FormatJSON: "ndjson", catalog.FormatXML: "xml"}
+	p.exportProducts(c, format, "products."+extension[format])
+}
+
+// ProductFeed serves the RSS product feed marketplaces poll.
+func (p *ProductController) ProductFeed(c *gin.Context) {
+	p.exportProducts(c, catalog.FormatXML, "")
+}
+
+func (p *ProductController) GetDeletedProduct(c *gin.Context) {
+	products, err := p.ProductRepo.GetDeleted(c.Request.Context())
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"products": products,
+	})
+}
+
+func (p *ProductController) RestoreProduct(c *gin.Context) {
+	if err := p.ProductRepo.Restore(c.Request.Context(), c.Param("id")); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Deleted product not found"))
+		return
+	}
+	recordAuditChanges(c, p.AuditRepo, "product", c.Param("id"), model.AuditRestore, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Product restored",
+	})
 }
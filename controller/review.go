@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"context"
+	"image-server/apierr"
+	"image-server/logging"
+	"image-server/metrics"
+	"image-server/model"
+	"image-server/reponsitory"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReviewController struct {
+	ReviewRepo  reponsitory.ReviewRepo
+	ProductRepo reponsitory.ProductRepo
+	UserRepo    reponsitory.UserRepo
+}
+
+func NewReviewController(ReviewRepo reponsitory.ReviewRepo, ProductRepo reponsitory.ProductRepo, UserRepo reponsitory.UserRepo) *ReviewController {
+	return &ReviewController{ReviewRepo: ReviewRepo, ProductRepo: ProductRepo, UserRepo: UserRepo}
+}
+
+// refreshRating recomputes the rating average and count stored on the product.
+func (r *ReviewController) refreshRating(ctx context.Context, productID primitive.ObjectID) {
+	average, count, err := r.ReviewRepo.Summary(ctx, productID)
+	if err != nil {
+		logging.FromContext(ctx).Error("summarise reviews", "product_id", productID.Hex(), "error", err)
+		return
+	}
+	if err := r.ProductRepo.UpdateRating(ctx, productID, average, count); err != nil {
+		logging.FromContext(ctx).Error("update product rating", "product_id", productID.Hex(), "error", err)
+	}
+}
+
+func (r *ReviewController) GetProductReviews(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("productId"))
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid product ID"))
+		return
+	}
+	reviews, err := r.ReviewRepo.GetByProduct(c.Request.Context(), productID)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+	})
+}
+
+func (r *ReviewController) CreateReview(c *gin.Context) {
+	var req model.ReviewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	product, err := r.ProductRepo.FindByID(c.Request.Context(), req.ProductID)
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	user, err := r.UserRepo.FindByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		apierr.Write(c, unknownUser(err))
+		return
+	}
+	review := model.Review{
+		ProductID:  product.ID,
+		UserID:     user.ID,
+		UserName:   user.Name,
+		Rating:     req.Rating,
+		Title:      strings.TrimSpace(req.Title),
+		Comment:    strings.TrimSpace(req.Comment),
+		Status:     model.ReviewPublished,
+		Created_At: time.Now(),
+		Updated_At: time.Now(),
+	}
+	review, err = r.ReviewRepo.Create(c.Request.Context(), review)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	r.refreshRating(c.Request.Context(), product.ID)
+	metrics.ReviewsCreated.Inc()
+	c.JSON(http.StatusOK, gin.H{
+		"review": review,
+	})
+}
+
+func (r *ReviewController) UpdateReview(c *gin.Context) {
+	review, err := r.ReviewRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Review not found"))
+		return
+	}
+	user, err := r.UserRepo.FindByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil || user.ID != review.UserID {
+		apierr.Write(c, apierr.New(apierr.CodeForbidden, "You can only edit your own review"))
+		return
+	}
+	var req model.ReviewUpdateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Rating != 0 {
+		review.Rating = req.Rating
+	}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		review.Title = title
+	}
+	if comment := strings.TrimSpace(req.Comment); comment != "" {
+		review.Comment = comment
+	}
+	review.Updated_At = time.Now()
+	review, err = r.ReviewRepo.Update(c.Request.Context(), review)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	r.refreshRating(c.Request.Context(), review.ProductID)
+	c.JSON(http.StatusOK, gin.H{
+		"review": review,
+	})
+}
+
+func (r *ReviewController) DeleteReview(c *gin.Context) {
+	review, err := r.ReviewRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Review not found"))
+		return
+	}
+	if c.GetString("role") != model.RoleAdmin {
+		user, err := r.UserRepo.FindByEmail(c.Request.Context(), c.GetString("email"))
+		if err != nil || user.ID != review.UserID {
+			apierr.Write(c, apierr.New(apierr.CodeForbidden, "You can only delete your own review"))
+			return
+		}
+	}
+	if err := r.ReviewRepo.Delete(c.Request.Context(), review.ID.Hex()); err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	r.refreshRating(c.Request.Context(), review.ProductID)
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Review deleted",
+	})
+}
+
+func (r *ReviewController) GetAllReview(c *gin.Context) {
+	reviews, err := r.ReviewRepo.GetAll(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+	})
+}
+
+func (r *ReviewController) ModerateReview(c *gin.Context) {
+	var req model.ReviewStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	review, err := r.ReviewRepo.UpdateStatus(c.Request.Context(), c.Param("id"), req.Status)
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Review not found"))
+		return
+	}
+	r.refreshRating(c.Request.Context(), review.ProductID)
+	c.JSON(http.StatusOK, gin.H{
+		"review": review,
+	})
+}
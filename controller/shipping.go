@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/model"
+	"image-server/reponsitory"
+	"image-server/shipping"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShippingController struct {
+	ProductRepo reponsitory.ProductRepo
+	AddressRepo reponsitory.AddressRepo
+	UserRepo    reponsitory.UserRepo
+	Calculator  *shipping.Calculator
+}
+
+func NewShippingController(ProductRepo reponsitory.ProductRepo, AddressRepo reponsitory.AddressRepo, UserRepo reponsitory.UserRepo, calculator *shipping.Calculator) *ShippingController {
+	return &ShippingController{ProductRepo: ProductRepo, AddressRepo: AddressRepo, UserRepo: UserRepo, Calculator: calculator}
+}
+
+// QuoteShipping returns the available shipping rates for a cart sent either
+// to a saved address (address_id) or to an ad-hoc country/region/postcode.
+func (s *ShippingController) QuoteShipping(c *gin.Context) {
+	var req model.CartQuoteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	destination, ok := resolveDestination(c, s.UserRepo, s.AddressRepo, req)
+	if !ok {
+		return
+	}
+	lines, err := loadCart(c.Request.Context(), s.ProductRepo, req.Items)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	shipment := shipping.Shipment{Destination: shipping.Destination(destination)}
+	for _, line := range lines {
+		shipment.Items = append(shipment.Items, shipping.Item{
+			ProductID: line.Product.ID.Hex(),
+			Quantity:  line.Quantity,
+			Price:     line.Product.CurrentPrice(time.Now()),
+			Weight:    line.Product.Weight,
+			Length:    line.Product.Length,
+			Width:     line.Product.Width,
+			Height:    line.Product.Height,
+		})
+	}
+	rates, err := s.Calculator.Quote(c.Request.Context(), shipment)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rates": rates,
+	})
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/model"
+	"image-server/reponsitory"
+	"image-server/tax"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxController struct {
+	ProductRepo reponsitory.ProductRepo
+	AddressRepo reponsitory.AddressRepo
+	UserRepo    reponsitory.UserRepo
+	Calculator  *tax.Calculator
+}
+
+func NewTaxController(ProductRepo reponsitory.ProductRepo, AddressRepo reponsitory.AddressRepo, UserRepo reponsitory.UserRepo, calculator *tax.Calculator) *TaxController {
+	return &TaxController{ProductRepo: ProductRepo, AddressRepo: AddressRepo, UserRepo: UserRepo, Calculator: calculator}
+}
+
+// QuoteTax returns the tax lines of a cart for a destination.
+func (t *TaxController) QuoteTax(c *gin.Context) {
+	var req model.CartQuoteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	destination, ok := resolveDestination(c, t.UserRepo, t.AddressRepo, req)
+	if !ok {
+		return
+	}
+	lines, err := loadCart(c.Request.Context(), t.ProductRepo, req.Items)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	taxLines := make([]tax.Line, 0, len(lines))
+	for _, line := range lines {
+		taxLines = append(taxLines, tax.Line{
+			ProductID: line.Product.ID.Hex(),
+			TaxClass:  line.Product.TaxClass,
+			UnitPrice: line.Product.CurrentPrice(time.Now()),
+			Quantity:  line.Quantity,
+		})
+	}
+	result, err := t.Calculator.Calculate(taxLines, tax.Destination{
+		Country: destination.Country,
+		Region:  destination.Region,
+	})
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tax": result,
+	})
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/i18n"
+	"image-server/model"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestLocale negotiates the response locale from ?locale= and
+// Accept-Language.
+func requestLocale(c *gin.Context) string {
+	return i18n.Negotiate(c.Query("locale"), c.GetHeader("Accept-Language"))
+}
+
+// translationLocale validates the :locale parameter of a translation edit:
+// a supported locale other than the default one, which is the untranslated
+// content. It writes the error response itself and reports false on failure.
+func translationLocale(c *gin.Context) (string, bool) {
+	locale := i18n.Normalize(c.Param("locale"))
+	if !i18n.Supported(locale) || locale == i18n.Normalize(i18n.DefaultLocale) {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Unsupported locale"))
+		return "", false
+	}
+	return locale, true
+}
+
+// bindTranslation validates the :locale parameter and the JSON body of a
+// translation edit. It writes the error response itself and reports false
+// on failure.
+func bindTranslation(c *gin.Context) (string, model.Translation, bool) {
+	locale, ok := translationLocale(c)
+	if !ok {
+		return "", model.Translation{}, false
+	}
+	var translation model.Translation
+	if !bindJSON(c, &translation) {
+		return "", model.Translation{}, false
+	}
+	translation.Name = strings.TrimSpace(translation.Name)
+	translation.Description = strings.TrimSpace(translation.Description)
+	return locale, translation, true
+}
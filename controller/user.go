@@ -1,12 +1,12 @@
 package controller
 
 import (
-	"bytes"
-	"encoding/json"
+	"image-server/apierr"
+	"image-server/mergepatch"
+	"image-server/metrics"
 	"image-server/model"
 	"image-server/reponsitory"
-	"io"
-	"log"
+	"image-server/storage"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,13 +15,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/gridfs"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserController struct {
-	UserRepo reponsitory.UserRepo
-	DB       *mongo.Database
+	UserRepo  reponsitory.UserRepo
+	DB        *mongo.Database
+	AuditRepo reponsitory.AuditRepo
 }
 
 func NewUserController(UserRepo reponsitory.UserRepo, db *mongo.Database) *UserController {
@@ -30,24 +29,23 @@ func NewUserController(UserRepo reponsitory.UserRepo, db *mongo.Database) *UserC
 }
 func (u *UserController) Login(c *gin.Context) {
 	var auth model.LoginRequest
-	if err := c.ShouldBind(&auth); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	if !bindForm(c, &auth) {
 		return
 	}
 	user, err := u.UserRepo.FindByEmail(c.Request.Context(), auth.Email)
+	if err == mongo.ErrNoDocuments {
+		metrics.LoginsFailed.Inc()
+		apierr.Write(c, errInvalidCredentials)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid credentials",
-		})
+		apierr.Write(c, err)
 		return
 	}
 	if auth.Email == user.Email && auth.Password == user.Password {
 		token, err := u.UserRepo.SaveToken(&user)
-		log.Print(token)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			apierr.Write(c, err)
 			return
 		}
 		cookie := http.Cookie{}
@@ -56,14 +54,14 @@ func (u *UserController) Login(c *gin.Context) {
 		cookie.Expires = time.Now().Add(15 * time.Minute)
 		http.SetCookie(c.Writer, &cookie)
 		c.JSON(http.StatusOK, gin.H{"token": token})
-		log.Print(token)
 	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid credentials",
-		})
+		metrics.LoginsFailed.Inc()
+		apierr.Write(c, errInvalidCredentials)
 	}
 }
 
+var errInvalidCredentials = apierr.New(apierr.CodeInvalidCredentials, "Invalid email or password")
+
 func (u *UserController) Logout(c *gin.Context) {
 	http.SetCookie(c.Writer, &http.Cookie{
 		Name:   "Token",
@@ -77,11 +75,9 @@ func (u *UserController) Logout(c *gin.Context) {
 }
 
 func (u *UserController) GetAllUser(c *gin.Context) {
-	users, err := u.UserRepo.GetAll(c)
+	users, err := u.UserRepo.GetAll(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		apierr.Write(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -90,59 +86,45 @@ func (u *UserController) GetAllUser(c *gin.Context) {
 }
 
 func (u *UserController) CreateUser(c *gin.Context) {
-	user := model.User{
-		Name:     c.Request.FormValue("name"),
-		Email:    c.Request.FormValue("email"),
-		Password: c.Request.FormValue("password"),
-	}
-	file, header, err := c.Request.FormFile("image")
-	log.Print(file)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image upload failed"})
+	var form model.UserForm
+	if !bindForm(c, &form) {
 		return
 	}
-	defer file.Close()
-	//Create GridFS bucket
-	bucket, err := gridfs.NewBucket(u.DB.Client().Database("test31"), options.GridFSBucket().SetName("photos"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create GridFS bucket"})
-		return
+	user := model.User{
+		Name:     strings.TrimSpace(form.Name),
+		Email:    form.Email,
+		Password: form.Password,
+		Role:     form.Role,
 	}
-	//Read image
-	buf := bytes.NewBuffer(nil)
-	if _, err := io.Copy(buf, file); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read image"})
-		return
+	if user.Role == "" {
+		user.Role = model.RoleCustomer
 	}
-	//Open upload stream
-	filename := time.Now().Format(time.RFC3339) + "_" + header.Filename
-	uploadStream, err := bucket.OpenUploadStream(filename)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not open upload stream"})
+	if user.Role != model.RoleCustomer && c.GetString("role") != model.RoleAdmin {
+		apierr.Write(c, apierr.New(apierr.CodeForbidden, "Only admins can assign roles"))
 		return
 	}
-	defer uploadStream.Close()
-	//Write to upload stream
-	fileSize, err := uploadStream.Write(buf.Bytes())
+	header := form.Image
+	file, err := header.Open()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not write to upload stream"})
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Image upload failed"))
 		return
 	}
-
-	// Save the file ID to the user model
-	fileId, err := json.Marshal(uploadStream.FileID)
+	defer file.Close()
+	fileID, fileSize, err := storage.UploadImage(c.Request.Context(), u.DB, storage.UserBucket, header.Filename, file)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not marshal file ID"})
+		apierr.Write(c, err)
 		return
 	}
-	user.UserImage_URL = strings.Trim(string(fileId), `"`)
+	user.UserImage_URL = fileID
 
 	// Insert the user into the database
 	users, err := u.UserRepo.Create(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not insert user"})
+		apierr.Write(c, err)
 		return
 	}
+	recordAudit(c, u.AuditRepo, "user", users.ID.Hex(), model.AuditCreate, nil, users)
+	metrics.UsersCreated.Inc()
 
 	c.JSON(http.StatusOK, gin.H{
 		"fileId":   user.UserImage_URL,
@@ -155,105 +137,226 @@ func (u *UserController) ServeImage(c *gin.Context) {
 	imageId := strings.TrimPrefix(c.Request.URL.Path, "/image/")
 	objID, err := primitive.ObjectIDFromHex(imageId)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image ID"})
+		apierr.Write(c, apierr.New(apierr.CodeNotFound, "Image not found"))
 		return
 	}
 
-	bucket, _ := gridfs.NewBucket(u.DB.Client().Database("test31"), options.GridFSBucket().SetName("photos"))
-
-	var buf bytes.Buffer
-	_, err = bucket.DownloadToStream(objID, &buf)
+	image, err := storage.DownloadImage(c.Request.Context(), u.DB, storage.UserBucket, objID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not download image"})
+		apierr.Write(c, apierr.NotFound(err, "Image not found"))
 		return
 	}
 
-	contentType := http.DetectContentType(buf.Bytes())
+	contentType := http.DetectContentType(image)
 	c.Writer.Header().Add("Content-Type", contentType)
-	c.Writer.Header().Add("Content-Length", strconv.Itoa(len(buf.Bytes())))
-	c.Writer.Write(buf.Bytes())
+	c.Writer.Header().Add("Content-Length", strconv.Itoa(len(image)))
+	c.Writer.Write(image)
+	metrics.GridFSBytesServed.WithLabelValues(storage.UserBucket).Add(float64(len(image)))
 }
 
 func (u *UserController) UpdateUser(c *gin.Context) {
 	userId := c.Param("id")
 	user, err := u.UserRepo.FindByID(c.Request.Context(), userId)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apierr.Write(c, apierr.NotFound(err, "User not found"))
+		return
+	}
+	var form model.UserUpdateForm
+	if !bindForm(c, &form) {
+		return
+	}
+	version, ok := expectedVersion(c, user.Version, form.Version)
+	if !ok {
+		return
+	}
+	if version != user.Version {
+		userVersionConflict(c, user)
 		return
 	}
+	before := user
 
-	if name := c.PostForm("name"); name != "" {
+	if name := strings.TrimSpace(form.Name); name != "" {
 		user.Name = name
 	}
-	if email := c.PostForm("email"); email != "" {
-		user.Email = email
+	if form.Email != "" {
+		user.Email = form.Email
+	}
+	if form.Password != "" {
+		user.Password = form.Password
 	}
-	if password := c.PostForm("password"); password != "" {
-		user.Password = password
+	if role := form.Role; role != "" && role != user.Role {
+		if c.GetString("role") != model.RoleAdmin {
+			apierr.Write(c, apierr.New(apierr.CodeForbidden, "Only admins can assign roles"))
+			return
+		}
+		user.Role = role
 	}
 
-	file, header, err := c.Request.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	imageURL, ok := u.uploadUserImage(c)
+	if !ok {
+		return
 	}
-	defer file.Close()
-	bucket, err := gridfs.NewBucket(u.DB.Client().Database("test31"), options.GridFSBucket().SetName("photos"))
+	if imageURL != "" {
+		user.UserImage_URL = imageURL
+	}
+	u.saveUser(c, before, user)
+}
+
+// PatchUser applies a JSON Merge Patch to the user, changing only the
+// fields present in the patch. The image is replaced through UpdateUserImage.
+func (u *UserController) PatchUser(c *gin.Context) {
+	user, err := u.UserRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not create GridFS bucket"})
+		apierr.Write(c, apierr.NotFound(err, "User not found"))
 		return
 	}
-
-	buf := bytes.NewBuffer(nil)
-	if _, err := io.Copy(buf, file); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read image"})
+	patch, bodyVersion, ok := readMergePatch(c)
+	if !ok {
+		return
+	}
+	version, ok := expectedVersion(c, user.Version, bodyVersion)
+	if !ok {
+		return
+	}
+	if version != user.Version {
+		userVersionConflict(c, user)
 		return
 	}
+	var fields model.UserPatch
+	if err := mergepatch.Merge(user.Patchable(), patch, &fields); err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return
+	}
+	if err := validate(fields); err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	before := user
+	user.ApplyPatch(fields)
+	if user.Role != before.Role {
+		if c.GetString("role") != model.RoleAdmin {
+			apierr.Write(c, apierr.New(apierr.CodeForbidden, "Only admins can assign roles"))
+			return
+		}
+	}
+	u.saveUser(c, before, user)
+}
 
-	filename := time.Now().Format(time.RFC3339) + "_" + header.Filename
-	uploadStream, err := bucket.OpenUploadStream(filename)
+// UpdateUserImage replaces the user image with the "image" upload.
+func (u *UserController) UpdateUserImage(c *gin.Context) {
+	user, err := u.UserRepo.FindByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not open upload stream"})
+		apierr.Write(c, apierr.NotFound(err, "User not found"))
 		return
 	}
-	defer uploadStream.Close()
-
-	if _, err := uploadStream.Write(buf.Bytes()); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not write to upload stream"})
+	version, ok := expectedVersion(c, user.Version, c.PostForm("version"))
+	if !ok {
+		return
+	}
+	if version != user.Version {
+		userVersionConflict(c, user)
 		return
 	}
+	imageURL, ok := u.uploadUserImage(c)
+	if !ok {
+		return
+	}
+	if imageURL == "" {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "image is required"))
+		return
+	}
+	before := user
+	user.UserImage_URL = imageURL
+	u.saveUser(c, before, user)
+}
 
-	fileId, err := json.Marshal(uploadStream.FileID)
+// uploadUserImage stores the optional "image" upload and returns its file
+// ID, or "" when no image was sent.
+func (u *UserController) uploadUserImage(c *gin.Context) (string, bool) {
+	file, header, err := c.Request.FormFile("image")
+	if err == http.ErrMissingFile {
+		return "", true
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not marshal file ID"})
-		return
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, err.Error()))
+		return "", false
+	}
+	defer file.Close()
+	fileID, _, err := storage.UploadImage(c.Request.Context(), u.DB, storage.UserBucket, header.Filename, file)
+	if err != nil {
+		apierr.Write(c, err)
+		return "", false
 	}
-	log.Print(fileId)
-	user.UserImage_URL = strings.Trim(string(fileId), `"`)
+	return fileID, true
+}
 
-	updatedUser, err := u.UserRepo.Update(c.Request.Context(), user)
+// saveUser writes the fields changed since before and records the audit entry.
+func (u *UserController) saveUser(c *gin.Context, before, user model.User) {
+	updatedUser, err := u.UserRepo.Patch(c.Request.Context(), before, user)
+	if err == reponsitory.ErrVersionConflict {
+		if current, err := u.UserRepo.FindByID(c.Request.Context(), before.ID.Hex()); err == nil {
+			userVersionConflict(c, current)
+			return
+		}
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Could not update user",
-			"err":   err.Error(),
-		})
+		apierr.Write(c, err)
 		return
 	}
+	recordAudit(c, u.AuditRepo, "user", user.ID.Hex(), model.AuditUpdate, before, updatedUser)
 
+	c.Header("ETag", etag(updatedUser.Version))
 	c.JSON(http.StatusOK, gin.H{
-		"user": updatedUser,
+		"user": updatedUser.Response(),
 	})
 }
 
+// userVersionConflict answers a stale update with the current user.
+func userVersionConflict(c *gin.Context, current model.User) {
+	c.Header("ETag", etag(current.Version))
+	apierr.Write(c, apierr.New(apierr.CodeVersionConflict, "User was modified by someone else").
+		With("user", current.Response()))
+}
+
 func (u *UserController) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid argument id"})
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "invalid argument id"))
+		return
+	}
+	before, err := u.UserRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "User not found"))
 		return
 	}
-	if err := u.UserRepo.Delete(c, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := u.UserRepo.Delete(c.Request.Context(), id); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "User not found"))
 		return
 	}
+	recordAudit(c, u.AuditRepo, "user", id, model.AuditDelete, before, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"data": "User deleted",
+	})
+}
+
+func (u *UserController) GetDeletedUser(c *gin.Context) {
+	users, err := u.UserRepo.GetDeleted(c.Request.Context())
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+	})
+}
+
+func (u *UserController) RestoreUser(c *gin.Context) {
+	if err := u.UserRepo.Restore(c.Request.Context(), c.Param("id")); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Deleted user not found"))
+		return
+	}
+	recordAuditChanges(c, u.AuditRepo, "user", c.Param("id"), model.AuditRestore, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"data": "User restored",
+	})
 }
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the entity tag of a document version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// expectedVersion reads the version the client last saw from If-Match or,
+// failing that, the version sent in the body. "*" matches the current
+// version. It writes 428 or 400 and reports false when none was sent.
+func expectedVersion(c *gin.Context, current int64, bodyVersion string) (int64, bool) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "*" {
+		return current, true
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	if value == "" {
+		value = bodyVersion
+	}
+	if value == "" {
+		apierr.Write(c, apierr.New(apierr.CodePreconditionRequired, "Send the If-Match header or a version field"))
+		return 0, false
+	}
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid version"))
+		return 0, false
+	}
+	return version, true
+}
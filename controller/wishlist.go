@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"image-server/apierr"
+	"image-server/model"
+	"image-server/reponsitory"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WishlistController struct {
+	WishlistRepo reponsitory.WishlistRepo
+	ProductRepo  reponsitory.ProductRepo
+	UserRepo     reponsitory.UserRepo
+}
+
+func NewWishlistController(WishlistRepo reponsitory.WishlistRepo, ProductRepo reponsitory.ProductRepo, UserRepo reponsitory.UserRepo) *WishlistController {
+	return &WishlistController{
+		WishlistRepo: WishlistRepo,
+		ProductRepo:  ProductRepo,
+		UserRepo:     UserRepo,
+	}
+}
+
+func (w *WishlistController) currentUser(c *gin.Context) (model.User, bool) {
+	user, err := w.UserRepo.FindByEmail(c.Request.Context(), c.GetString("email"))
+	if err != nil {
+		apierr.Write(c, unknownUser(err))
+		return model.User{}, false
+	}
+	return user, true
+}
+
+func (w *WishlistController) GetWishlist(c *gin.Context) {
+	user, ok := w.currentUser(c)
+	if !ok {
+		return
+	}
+	items, err := w.WishlistRepo.GetByUser(c.Request.Context(), user.ID)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	ids := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	products, err := w.ProductRepo.FindByIDs(c.Request.Context(), ids)
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	wishlist := []model.WishlistResponse{}
+	for _, item := range items {
+		// Deleted products are hidden but kept, so restoring one brings it
+		// back; the purge job drops entries of purged products.
+		product, ok := products[item.ProductID]
+		if !ok {
+			continue
+		}
+		wishlist = append(wishlist, model.WishlistResponse{
+			Product:       product,
+			NotifyInStock: item.NotifyInStock,
+			Created_At:    item.Created_At,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"wishlist": wishlist,
+	})
+}
+
+func (w *WishlistController) AddToWishlist(c *gin.Context) {
+	var req model.WishlistRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	product, err := w.ProductRepo.FindByID(c.Request.Context(), req.ProductID)
+	if err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product not found"))
+		return
+	}
+	user, ok := w.currentUser(c)
+	if !ok {
+		return
+	}
+	item, err := w.WishlistRepo.Add(c.Request.Context(), model.WishlistItem{
+		UserID:        user.ID,
+		ProductID:     product.ID,
+		NotifyInStock: req.NotifyInStock,
+		Created_At:    time.Now(),
+	})
+	if err != nil {
+		apierr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"item": item,
+	})
+}
+
+func (w *WishlistController) RemoveFromWishlist(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("productId"))
+	if err != nil {
+		apierr.Write(c, apierr.New(apierr.CodeBadRequest, "Invalid product ID"))
+		return
+	}
+	user, ok := w.currentUser(c)
+	if !ok {
+		return
+	}
+	if err := w.WishlistRepo.Remove(c.Request.Context(), user.ID, productID); err != nil {
+		apierr.Write(c, apierr.NotFound(err, "Product is not in wishlist"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": "Removed from wishlist",
+	})
+}
@@ -2,27 +2,56 @@ package db
 
 import (
 	"context"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func ConnectDB() *mongo.Client {
+// ConnectDB connects to uri, applying opts on top, and pings the server.
+func ConnectDB(uri string, opts ...*options.ClientOptions) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	// mongo.Connect return mongo.Client method
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("MONGOURI")))
+	client, err := mongo.Connect(ctx, append([]*options.ClientOptions{options.Client().ApplyURI(uri)}, opts...)...)
 	if err != nil {
-		log.Fatal("Error: " + err.Error())
+		return nil, err
 	}
 	//ping the database
 	err = client.Ping(ctx, nil)
 	if err != nil {
-		log.Fatal("Error: " + err.Error())
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+	slog.Info("connected to MongoDB")
+	return client, nil
+}
+
+// Monitors combines command monitors, since the client takes only one.
+func Monitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
 	}
-	log.Println("Connected to MongoDB")
-	return client
 }
@@ -0,0 +1,97 @@
+// Package i18n picks the locale a request is served in.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the language base content such as ProductName is written
+// in. It is always supported.
+var DefaultLocale = "vi"
+
+// SupportedLocales lists the locales translations may be stored for besides
+// DefaultLocale.
+var SupportedLocales = []string{"en"}
+
+// Normalize lower-cases a tag and uses "-" as separator, e.g. "en_US" -> "en-us".
+func Normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+func Supported(locale string) bool {
+	locale = Normalize(locale)
+	if locale == Normalize(DefaultLocale) {
+		return true
+	}
+	for _, l := range SupportedLocales {
+		if Normalize(l) == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the supported locale for a tag, trying the full tag first
+// and then its primary language ("en-gb" -> "en").
+func match(tag string) (string, bool) {
+	tag = Normalize(tag)
+	if Supported(tag) {
+		return tag, true
+	}
+	if base, _, ok := strings.Cut(tag, "-"); ok && Supported(base) {
+		return base, true
+	}
+	return "", false
+}
+
+type weighted struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage returns the tags of an Accept-Language header sorted by
+// quality, dropping q=0 and wildcards.
+func parseAcceptLanguage(header string) []string {
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// Negotiate chooses the locale from an explicit query value, then the
+// Accept-Language header, falling back to DefaultLocale.
+func Negotiate(query, acceptLanguage string) string {
+	if query != "" {
+		if locale, ok := match(query); ok {
+			return locale
+		}
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := match(tag); ok {
+			return locale
+		}
+	}
+	return Normalize(DefaultLocale)
+}
@@ -0,0 +1,119 @@
+package job
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/reponsitory"
+	"image-server/tracing"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PriceScheduler applies scheduled price changes and starts and ends sales,
+// recording each change in the price history.
+type PriceScheduler struct {
+	Products reponsitory.ProductRepo
+	Prices   reponsitory.PriceRepo
+}
+
+func NewPriceScheduler(products reponsitory.ProductRepo, prices reponsitory.PriceRepo) *PriceScheduler {
+	return &PriceScheduler{Products: products, Prices: prices}
+}
+
+// Run applies due schedules once at start and then every interval, until
+// ctx is cancelled. Sale prices are only shown inside their window, so a
+// late tick delays the history entry but never the end of a sale.
+func (s *PriceScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Apply(ctx, time.Now()); err != nil {
+			slog.Error("apply price schedules", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Apply processes every schedule due at now, in one trace per run.
+func (s *PriceScheduler) Apply(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "job.price_schedules")
+	defer span.End()
+	due, err := s.Prices.Due(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range due {
+		if err := s.apply(ctx, schedule, now); err != nil {
+			slog.Error("apply price schedule", "schedule_id", schedule.ID.Hex(), "error", err)
+		}
+	}
+	return nil
+}
+
+// apply claims the schedule first, so a schedule picked up by another
+// instance of the job, or cancelled meanwhile, is skipped. A failed schedule
+// is released to be retried on the next run, and one whose job died is
+// claimed again once its lease runs out.
+func (s *PriceScheduler) apply(ctx context.Context, schedule model.PriceSchedule, now time.Time) error {
+	claimed, err := s.Prices.ClaimSchedule(ctx, schedule, now)
+	if err != nil || !claimed {
+		return err
+	}
+	if schedule.Status == model.ScheduleApplying {
+		schedule.Status = schedule.ClaimedFrom
+	}
+	if err := s.run(ctx, schedule, now); err != nil {
+		if release := s.Prices.SetScheduleStatus(ctx, schedule.ID, schedule.Status); release != nil {
+			slog.Error("release price schedule", "schedule_id", schedule.ID.Hex(), "error", release)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *PriceScheduler) run(ctx context.Context, schedule model.PriceSchedule, now time.Time) error {
+	product, err := s.Products.FindByID(ctx, schedule.ProductID.Hex())
+	if err == mongo.ErrNoDocuments {
+		return s.Prices.SetScheduleStatus(ctx, schedule.ID, model.ScheduleCancelled)
+	}
+	if err != nil {
+		return err
+	}
+	entry := model.PriceHistory{ProductID: product.ID, Actor: schedule.Actor, Changed_At: now}
+	status := model.ScheduleApplied
+	switch {
+	case schedule.Kind == model.PriceChangeKind:
+		if err := s.Products.SetPrice(ctx, product.ID, schedule.Price); err != nil {
+			return err
+		}
+		entry.Previous, entry.Price, entry.Reason = product.Price, schedule.Price, model.PriceReasonScheduled
+	case schedule.Status == model.ScheduleScheduled && schedule.EndsAt.After(now):
+		sale := &model.Sale{ScheduleID: schedule.ID, Price: schedule.Price, StartsAt: schedule.StartsAt, EndsAt: schedule.EndsAt}
+		if err := s.Products.SetSale(ctx, product.ID, sale); err != nil {
+			return err
+		}
+		entry.Previous, entry.Price, entry.Reason = product.Price, schedule.Price, model.PriceReasonSaleStart
+		status = model.ScheduleActive
+	case schedule.Status == model.ScheduleScheduled:
+		// The whole sale window passed while the job was not running.
+		return s.Prices.SetScheduleStatus(ctx, schedule.ID, model.ScheduleExpired)
+	default:
+		if product.Sale != nil && product.Sale.ScheduleID == schedule.ID {
+			if err := s.Products.SetSale(ctx, product.ID, nil); err != nil {
+				return err
+			}
+		}
+		entry.Previous, entry.Price, entry.Reason = schedule.Price, product.Price, model.PriceReasonSaleEnd
+		status = model.ScheduleExpired
+	}
+	if err := s.Prices.RecordChange(ctx, entry); err != nil {
+		return err
+	}
+	return s.Prices.SetScheduleStatus(ctx, schedule.ID, status)
+}
@@ -0,0 +1,65 @@
+// Package job runs background maintenance tasks.
+package job
+
+import (
+	"context"
+	"image-server/reponsitory"
+	"image-server/tracing"
+	"log/slog"
+	"time"
+)
+
+// Purger permanently removes records soft-deleted before a cutoff.
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ProductPurger purges products and drops the wishlist entries pointing at
+// them. Soft-deleted products stay in wishlists until then, so a restored
+// product reappears.
+type ProductPurger struct {
+	Products  reponsitory.ProductRepo
+	Wishlists reponsitory.WishlistRepo
+}
+
+func (p ProductPurger) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ids, err := p.Products.Purge(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.Wishlists.RemoveProducts(ctx, ids); err != nil {
+		slog.Error("prune purged products from wishlists", "error", err)
+	}
+	return int64(len(ids)), nil
+}
+
+// RunPurge purges records soft-deleted longer than retention ago, once at
+// start and then every interval, until ctx is cancelled.
+func RunPurge(ctx context.Context, retention, interval time.Duration, purgers map[string]Purger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purge(ctx, time.Now().Add(-retention), purgers)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge runs every purger once, in one trace.
+func purge(ctx context.Context, cutoff time.Time, purgers map[string]Purger) {
+	ctx, span := tracing.Start(ctx, "job.purge")
+	defer span.End()
+	for name, purger := range purgers {
+		count, err := purger.Purge(ctx, cutoff)
+		if err != nil {
+			slog.Error("purge deleted records", "collection", name, "error", err)
+			continue
+		}
+		if count > 0 {
+			slog.Info("purged deleted records", "collection", name, "count", count)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package logging sets up the structured JSON logger and carries the
+// per-request logger through contexts.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secret values in log records.
+const Redacted = "[redacted]"
+
+var secretKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"secret":        true,
+	"secret_key":    true,
+	"claims":        true,
+}
+
+// jwtPattern matches compact JWS tokens, which always start with a base64
+// encoded JSON header.
+var jwtPattern = regexp.MustCompile(`eyJ[\w-]*\.[\w-]+\.[\w-]*`)
+
+// redact hides attributes named like secrets and any value that carries a
+// bearer token, whatever its key.
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	var s string
+	switch a.Value.Kind() {
+	case slog.KindString:
+		s = a.Value.String()
+	case slog.KindAny:
+		err, ok := a.Value.Any().(error)
+		if !ok {
+			return a
+		}
+		s = err.Error()
+	default:
+		return a
+	}
+	if jwtPattern.MatchString(s) {
+		return slog.String(a.Key, jwtPattern.ReplaceAllString(s, Redacted))
+	}
+	return a
+}
+
+// New returns a JSON logger writing records at level and above to w.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}))
+}
+
+// ParseLevel reads debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored by WithLogger, which is tagged with
+// the request ID, or the default logger outside a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
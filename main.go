@@ -1,24 +1,137 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image-server/config"
 	"image-server/db"
+	"image-server/job"
+	"image-server/logging"
+	"image-server/metrics"
+	"image-server/migration"
+	"image-server/reponsitory"
 	"image-server/route"
-	"log"
+	"image-server/tracing"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// fatal logs err and exits; deferred calls do not run.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func main() {
-	err := godotenv.Load()
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
 	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-	client := db.ConnectDB()
-	db := client.Database(os.Getenv("DB_NAME"))
-	port := os.Getenv("PORT")
-	r := gin.Default()
-	route.Route(r, db)
-	r.Run(":" + port)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:\n"+err.Error())
+		os.Exit(2)
+	}
+	cfg.Apply()
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	slog.SetDefault(logging.New(os.Stdout, level))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	exporter, err := tracing.NewExporter(ctx, cfg.TraceExporter, cfg.TraceEndpoint, os.Stdout)
+	if err != nil {
+		fatal("creating trace exporter", err)
+	}
+	shutdownTracing := tracing.Setup(exporter)
+
+	monitor := db.Monitors(metrics.CommandMonitor(), tracing.CommandMonitor())
+	client, err := db.ConnectDB(cfg.MongoURI, options.Client().SetMonitor(monitor))
+	if err != nil {
+		fatal("connecting to MongoDB", err)
+	}
+	db := client.Database(cfg.DBName)
+	if err := migration.Run(ctx, db, migration.All); err != nil {
+		fatal("applying migrations", err)
+	}
+	promoted, err := reponsitory.NewUserRepo(db, cfg.SecretKey).PromoteAdmins(ctx, cfg.AdminEmails)
+	if err != nil {
+		fatal("promoting admins", err)
+	}
+	if promoted > 0 {
+		slog.Info("promoted users to admin", "count", promoted)
+	}
+
+	// Access logs and panic recovery come from the route middleware, so
+	// gin's own logger is left out.
+	r := gin.New()
+	if err := route.Route(r, db, cfg); err != nil {
+		fatal("registering routes", err)
+	}
+
+	var jobs sync.WaitGroup
+	jobs.Add(2)
+	go func() {
+		defer jobs.Done()
+		job.RunPurge(ctx, cfg.SoftDeleteRetention, time.Hour, map[string]job.Purger{
+			"products": job.ProductPurger{
+				Products:  reponsitory.NewProductRepo(db),
+				Wishlists: reponsitory.NewWishlistRepo(db),
+			},
+			"users": reponsitory.NewUserRepo(db, cfg.SecretKey),
+		})
+	}()
+	go func() {
+		defer jobs.Done()
+		job.NewPriceScheduler(reponsitory.NewProductRepo(db), reponsitory.NewPriceRepo(db)).Run(ctx, time.Minute)
+	}()
+
+	server := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	slog.Info("listening", "addr", server.Addr)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server error", "error", err)
+		}
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	}
+	stop()
+
+	// Stop accepting connections and let in-flight requests finish, then
+	// wait for the background jobs before closing the Mongo client they use.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("forced shutdown", "error", err)
+	}
+	jobs.Wait()
+	if err := client.Disconnect(shutdownCtx); err != nil {
+		slog.Error("disconnecting from MongoDB", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("flushing traces", "error", err)
+	}
+	slog.Info("stopped")
 }
@@ -0,0 +1,63 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7386).
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const ContentType = "application/merge-patch+json"
+
+// Apply merges patch into target. Members of patch set to null are removed
+// from target, objects are merged recursively and any other value replaces
+// the target value.
+func Apply(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = Apply(t[key], value)
+	}
+	return t
+}
+
+// Decode parses a JSON document keeping numbers as json.Number, so amounts
+// survive a round trip without float rounding.
+func Decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Merge applies a decoded patch to the JSON encoding of v and decodes the
+// result into out. Members unknown to out are rejected.
+func Merge(v interface{}, patch interface{}, out interface{}) error {
+	current, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	target, err := Decode(current)
+	if err != nil {
+		return err
+	}
+	merged, err := json.Marshal(Apply(target, patch))
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(out)
+}
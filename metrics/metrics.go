@@ -0,0 +1,91 @@
+// Package metrics defines the Prometheus metrics served on /metrics.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_command_duration_seconds",
+		Help:    "MongoDB command latency by command and outcome.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "outcome"})
+
+	// GridFSBytesUploaded and GridFSBytesServed count image bytes per bucket.
+	GridFSBytesUploaded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gridfs_uploaded_bytes_total",
+		Help: "Bytes written to GridFS by bucket.",
+	}, []string{"bucket"})
+	GridFSBytesServed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gridfs_served_bytes_total",
+		Help: "Bytes read from GridFS and sent to clients by bucket.",
+	}, []string{"bucket"})
+
+	ProductsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecom_products_created_total",
+		Help: "Products created through the API or imports.",
+	})
+	UsersCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecom_users_created_total",
+		Help: "Users created.",
+	})
+	LoginsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecom_logins_failed_total",
+		Help: "Login attempts rejected for bad credentials.",
+	})
+	ReviewsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecom_reviews_created_total",
+		Help: "Product reviews written.",
+	})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// HTTP records the count and latency of every request. Requests that match
+// no route share the route label "unmatched" to bound cardinality.
+func HTTP(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(c.Writer.Status())
+	httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+	httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+}
+
+// CommandMonitor times every MongoDB command; pass it to the client options.
+func CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "success").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "failure").Observe(e.Duration.Seconds())
+		},
+	}
+}
@@ -2,20 +2,26 @@ package middleware
 
 import (
 	"fmt"
-	"log"
-	"net/http"
-	"os"
+	"image-server/apierr"
+	"image-server/model"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(c *gin.Context) {
+// AuthMiddleware verifies the bearer token signed with secret and stores
+// its email and role claims in the context.
+func AuthMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticate(c, []byte(secret))
+	}
+}
+
+func authenticate(c *gin.Context, secret []byte) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-		c.Abort()
+		apierr.Abort(c, apierr.New(apierr.CodeUnauthenticated, "Authorization header required"))
 		return
 	}
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
@@ -25,30 +31,34 @@ func AuthMiddleware(c *gin.Context) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(os.Getenv("SECRET_KEY")), nil
+		return secret, nil
 	})
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-		c.Abort()
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidToken, "Invalid token"))
 		return
 	}
-	log.Print(token)
-
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		log.Printf("Failed to parse claims: %#v", ok)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-		c.Abort()
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidToken, "Invalid token claims"))
 		return
 	}
-
-	log.Printf("claims: %+v", claims)
 	emailClaim, ok := claims["sub"].(string)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Email claim not found"})
-		c.Abort()
+		apierr.Abort(c, apierr.New(apierr.CodeInvalidToken, "Email claim not found"))
 		return
 	}
 	c.Set("email", emailClaim)
+	if roleClaim, ok := claims["role"].(string); ok {
+		c.Set("role", roleClaim)
+	}
+	c.Next()
+}
+
+// AdminMiddleware must run after AuthMiddleware and rejects non-admin users.
+func AdminMiddleware(c *gin.Context) {
+	if c.GetString("role") != model.RoleAdmin {
+		apierr.Abort(c, apierr.New(apierr.CodeForbidden, "Admin access required"))
+		return
+	}
 	c.Next()
 }
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"image-server/apierr"
+	"image-server/logging"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog writes one record per request once it completes. Only the path
+// is logged: query strings may carry tokens.
+func AccessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	level := slog.LevelInfo
+	if c.Writer.Status() >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request",
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("route", c.FullPath()),
+		slog.Int("status", c.Writer.Status()),
+		slog.Int("bytes", c.Writer.Size()),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.String("client_ip", c.ClientIP()),
+		slog.String("user_agent", c.Request.UserAgent()),
+	)
+}
+
+// Recovery turns a panic into a 500 response and logs it with the stack.
+var Recovery = gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+	logging.FromContext(c.Request.Context()).Error("panic serving request",
+		"panic", recovered,
+		"stack", string(debug.Stack()),
+	)
+	apierr.Abort(c, apierr.New(apierr.CodeInternal, "Internal server error"))
+})
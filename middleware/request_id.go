@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"image-server/logging"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID propagates the caller's X-Request-ID or generates one, stores it
+// as "request_id" in the context, echoes it in the response and tags the
+// request logger with it.
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if id == "" || len(id) > 128 {
+		buf := make([]byte, 16)
+		rand.Read(buf)
+		id = hex.EncodeToString(buf)
+	}
+	c.Set("request_id", id)
+	c.Header(RequestIDHeader, id)
+	logger := slog.Default().With("request_id", id)
+	c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+	c.Next()
+}
@@ -0,0 +1,91 @@
+// Package migration applies one-off data migrations and records them in the
+// "migrations" collection so each runs exactly once per database.
+package migration
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+type record struct {
+	ID          string    `bson:"_id"`
+	Description string    `bson:"description"`
+	Applied_At  time.Time `bson:"applied_at"`
+}
+
+// All lists the migrations in the order they must be applied.
+var All = []Migration{
+	priceToMoney,
+	productSlugs,
+	documentVersions,
+	uniqueReviews,
+	uniqueProductSKU,
+	uniqueWishlistItems,
+}
+
+func applied(ctx context.Context, db *mongo.Database) (map[string]bool, error) {
+	cursor, err := db.Collection("migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(records))
+	for _, r := range records {
+		done[r.ID] = true
+	}
+	return done, nil
+}
+
+// Pending returns the IDs of migrations that have not been applied yet.
+func Pending(ctx context.Context, db *mongo.Database, migrations []Migration) ([]string, error) {
+	done, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, m := range migrations {
+		if !done[m.ID] {
+			pending = append(pending, m.ID)
+		}
+	}
+	return pending, nil
+}
+
+// Run applies every pending migration in order and stops at the first error.
+func Run(ctx context.Context, db *mongo.Database, migrations []Migration) error {
+	done, err := applied(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if done[m.ID] {
+			continue
+		}
+		slog.Info("applying migration", "id", m.ID, "description", m.Description)
+		if err := m.Up(ctx, db); err != nil {
+			return err
+		}
+		_, err := db.Collection("migrations").InsertOne(ctx, record{
+			ID:          m.ID,
+			Description: m.Description,
+			Applied_At:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"context"
+	"image-server/money"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// priceToMoney rewrites float64 product prices as money documents in
+// money.DefaultCurrency.
+var priceToMoney = Migration{
+	ID:          "0001_price_to_money",
+	Description: "convert products.price from float64 to money",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		products := db.Collection("products")
+		cursor, err := products.Find(ctx, bson.M{"price": bson.M{"$type": "number"}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var doc struct {
+				ID    primitive.ObjectID `bson:"_id"`
+				Price bson.RawValue      `bson:"price"`
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				return err
+			}
+			var price money.Money
+			if err := price.UnmarshalBSONValue(doc.Price.Type, doc.Price.Value); err != nil {
+				return err
+			}
+			if _, err := products.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"price": price}}); err != nil {
+				return err
+			}
+		}
+		return cursor.Err()
+	},
+}
@@ -0,0 +1,24 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// uniqueProductSKU indexes the SKU the catalog import upserts by. Products
+// without a SKU are left out of the index.
+var uniqueProductSKU = Migration{
+	ID:          "0005_unique_product_sku",
+	Description: "index products by unique sku",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection("products").Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "sku", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"sku": bson.M{"$type": "string", "$gt": ""}}),
+		})
+		return err
+	},
+}
@@ -0,0 +1,49 @@
+package migration
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/reponsitory"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// productSlugs gives every existing product a slug and indexes slug lookups.
+var productSlugs = Migration{
+	ID:          "0002_product_slugs",
+	Description: "backfill product slugs and index slug and slug_history",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		products := db.Collection("products")
+		repo := reponsitory.NewProductRepo(db)
+		cursor, err := products.Find(ctx, bson.M{"$or": bson.A{
+			bson.M{"slug": bson.M{"$exists": false}},
+			bson.M{"slug": ""},
+		}})
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var product model.Product
+			if err := cursor.Decode(&product); err != nil {
+				return err
+			}
+			if err := repo.RefreshSlug(ctx, &product); err != nil {
+				return err
+			}
+			if _, err := products.UpdateOne(ctx, bson.M{"_id": product.ID}, bson.M{"$set": bson.M{"slug": product.Slug}}); err != nil {
+				return err
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			return err
+		}
+		_, err = products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "slug_history", Value: 1}}},
+		})
+		return err
+	},
+}
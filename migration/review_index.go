@@ -0,0 +1,51 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// uniqueReviews enforces one review per user and product. Duplicates left by
+// concurrent submissions keep their oldest review.
+var uniqueReviews = Migration{
+	ID:          "0004_unique_reviews",
+	Description: "drop duplicate reviews and index reviews by user_id and product_id",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		return uniqueUserProduct(ctx, db.Collection("reviews"))
+	},
+}
+
+// uniqueUserProduct deletes all but the oldest document of each user_id and
+// product_id pair in coll, then indexes the pair as unique.
+func uniqueUserProduct(ctx context.Context, coll *mongo.Collection) error {
+	cursor, err := coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "user_id", Value: "$user_id"}, {Key: "product_id", Value: "$product_id"}}},
+			{Key: "ids", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "ids.1", Value: bson.D{{Key: "$exists", Value: true}}}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var groups []struct {
+		IDs bson.A `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if _, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}}); err != nil {
+			return err
+		}
+	}
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,26 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// documentVersions starts optimistic concurrency at version 1 for products
+// and users written before documents carried a version.
+var documentVersions = Migration{
+	ID:          "0003_document_versions",
+	Description: "set version 1 on products and users without a version",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		for _, name := range []string{"products", "users"} {
+			_, err := db.Collection(name).UpdateMany(ctx,
+				bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": int64(1)}})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// uniqueWishlistItems keeps one wishlist entry per user and product, so
+// concurrent adds cannot store the product twice and notify twice.
+var uniqueWishlistItems = Migration{
+	ID:          "0006_unique_wishlist_items",
+	Description: "drop duplicate wishlist entries and index wishlists by user_id and product_id",
+	Up: func(ctx context.Context, db *mongo.Database) error {
+		return uniqueUserProduct(ctx, db.Collection("wishlists"))
+	},
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Address struct {
+	ID                primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID            primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Label             string             `json:"label" bson:"label"`
+	FullName          string             `json:"full_name" bson:"full_name"`
+	Phone             string             `json:"phone" bson:"phone"`
+	Line1             string             `json:"line1" bson:"line1"`
+	Line2             string             `json:"line2" bson:"line2"`
+	City              string             `json:"city" bson:"city"`
+	Region            string             `json:"region" bson:"region"`
+	PostalCode        string             `json:"postal_code" bson:"postal_code"`
+	Country           string             `json:"country" bson:"country"`
+	IsDefaultShipping bool               `json:"is_default_shipping" bson:"is_default_shipping"`
+	IsDefaultBilling  bool               `json:"is_default_billing" bson:"is_default_billing"`
+	Created_At        time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At        time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type AddressRequest struct {
+	Label             string `json:"label" binding:"max=50"`
+	FullName          string `json:"full_name" binding:"max=100"`
+	Phone             string `json:"phone" binding:"max=32"`
+	Line1             string `json:"line1" binding:"max=200"`
+	Line2             string `json:"line2" binding:"max=200"`
+	City              string `json:"city" binding:"max=100"`
+	Region            string `json:"region" binding:"max=100"`
+	PostalCode        string `json:"postal_code" binding:"max=20"`
+	Country           string `json:"country" binding:"omitempty,len=2,alpha"`
+	IsDefaultShipping *bool  `json:"is_default_shipping"`
+	IsDefaultBilling  *bool  `json:"is_default_billing"`
+}
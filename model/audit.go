@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+type AuditEntry struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Entity     string             `json:"entity" bson:"entity"`
+	EntityID   string             `json:"entity_id" bson:"entity_id"`
+	Action     string             `json:"action" bson:"action"`
+	Actor      string             `json:"actor" bson:"actor"`
+	RequestID  string             `json:"request_id" bson:"request_id"`
+	Changes    []FieldChange      `json:"changes" bson:"changes"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	Actor    string
+	Action   string
+	From     time.Time
+	To       time.Time
+	Limit    int64
+}
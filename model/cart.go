@@ -0,0 +1,16 @@
+package model
+
+type CartItem struct {
+	ProductID string `json:"product_id" binding:"required,mongodb"`
+	Quantity  int    `json:"quantity" binding:"gt=0"`
+}
+
+// CartQuoteRequest prices a cart for a destination given either as a saved
+// address (address_id) or as an ad-hoc country/region/postcode.
+type CartQuoteRequest struct {
+	Items      []CartItem `json:"items" binding:"required,min=1,dive"`
+	AddressID  string     `json:"address_id" binding:"omitempty,mongodb"`
+	Country    string     `json:"country" binding:"omitempty,len=2,alpha"`
+	Region     string     `json:"region" binding:"max=100"`
+	PostalCode string     `json:"postal_code" binding:"max=20"`
+}
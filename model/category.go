@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Category struct {
+	ID           primitive.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	Name         string                 `json:"name" bson:"name"`
+	Description  string                 `json:"description" bson:"description"`
+	Translations map[string]Translation `json:"translations,omitempty" bson:"translations,omitempty"`
+	Created_At   time.Time              `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+type CategoryRequest struct {
+	Name        string `json:"name" binding:"notblank,max=100"`
+	Description string `json:"description" binding:"max=2000"`
+}
+
+// CategoryUpdateRequest changes only the non-empty fields.
+type CategoryUpdateRequest struct {
+	Name        string `json:"name" binding:"max=100"`
+	Description string `json:"description" binding:"max=2000"`
+}
+
+type CategoryResponse struct {
+	ID          string `json:"_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Locale      string `json:"locale,omitempty"`
+}
+
+// Localize renders the category in locale, falling back per field to the
+// default-locale content.
+func (c Category) Localize(locale string) CategoryResponse {
+	response := CategoryResponse{
+		ID:          c.ID.Hex(),
+		Name:        c.Name,
+		Description: c.Description,
+		Locale:      locale,
+	}
+	if t, ok := c.Translations[locale]; ok {
+		if t.Name != "" {
+			response.Name = t.Name
+		}
+		if t.Description != "" {
+			response.Description = t.Description
+		}
+	}
+	return response
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"image-server/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExchangeRates is an immutable snapshot of the rate table. Every change is
+// stored as a new document so orders can reference the rates they used.
+type ExchangeRates struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Base       string             `json:"base" bson:"base"`
+	Rates      map[string]float64 `json:"rates" bson:"rates"`
+	Source     string             `json:"source" bson:"source"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+}
+
+func (e ExchangeRates) Table() money.RateTable {
+	return money.RateTable{Base: e.Base, Rates: e.Rates}
+}
+
+type ExchangeRatesRequest struct {
+	Base  string             `json:"base" binding:"required,len=3"`
+	Rates map[string]float64 `json:"rates" binding:"required,min=1,dive,keys,len=3,endkeys,gt=0"`
+}
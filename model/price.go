@@ -0,0 +1,79 @@
+package model
+
+import (
+	"image-server/money"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PriceChangeKind = "price"
+	SaleKind        = "sale"
+)
+
+const (
+	ScheduleScheduled = "scheduled"
+	ScheduleActive    = "active"
+	// ScheduleApplying marks a schedule claimed by a running price job.
+	ScheduleApplying  = "applying"
+	ScheduleApplied   = "applied"
+	ScheduleExpired   = "expired"
+	ScheduleCancelled = "cancelled"
+)
+
+const (
+	PriceReasonUpdate    = "update"
+	PriceReasonImport    = "import"
+	PriceReasonScheduled = "scheduled"
+	PriceReasonSaleStart = "sale_start"
+	PriceReasonSaleEnd   = "sale_end"
+)
+
+// Sale is a time-boxed price shown instead of the regular price.
+type Sale struct {
+	ScheduleID primitive.ObjectID `json:"schedule_id,omitempty" bson:"schedule_id,omitempty"`
+	Price      money.Money        `json:"price" bson:"price"`
+	StartsAt   time.Time          `json:"starts_at" bson:"starts_at"`
+	EndsAt     time.Time          `json:"ends_at" bson:"ends_at"`
+}
+
+// ActiveAt reports whether the sale price applies at t.
+func (s *Sale) ActiveAt(t time.Time) bool {
+	return s != nil && !t.Before(s.StartsAt) && t.Before(s.EndsAt)
+}
+
+// PriceSchedule is a future regular price change or a sale window.
+type PriceSchedule struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	ProductID  primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Kind       string             `json:"kind" bson:"kind"`
+	Price      money.Money        `json:"price" bson:"price"`
+	StartsAt   time.Time          `json:"starts_at" bson:"starts_at"`
+	EndsAt     time.Time          `json:"ends_at,omitempty" bson:"ends_at,omitempty"`
+	Status     string             `json:"status" bson:"status"`
+	Actor      string             `json:"actor" bson:"actor"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+	// ClaimedFrom and Claimed_At are set while a price job applies the
+	// schedule: the status it had before and when the claim was taken.
+	ClaimedFrom string     `json:"-" bson:"claimed_from,omitempty"`
+	Claimed_At  *time.Time `json:"-" bson:"claimed_at,omitempty"`
+}
+
+type PriceScheduleRequest struct {
+	Kind     string      `json:"kind" binding:"required,oneof=price sale"`
+	Price    money.Money `json:"price"`
+	StartsAt time.Time   `json:"starts_at"`
+	EndsAt   time.Time   `json:"ends_at"`
+}
+
+// PriceHistory records one change of the price customers pay.
+type PriceHistory struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	ProductID  primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Previous   money.Money        `json:"previous" bson:"previous"`
+	Price      money.Money        `json:"price" bson:"price"`
+	Reason     string             `json:"reason" bson:"reason"`
+	Actor      string             `json:"actor,omitempty" bson:"actor,omitempty"`
+	Changed_At time.Time          `json:"changed_at" bson:"changed_at"`
+}
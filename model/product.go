@@ -1,29 +1,239 @@
 package model
 
 import (
+	"image-server/money"
+	"mime/multipart"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Product struct {
-	ID               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	ProductName      string             `json:"productname" bson:"productname"`
-	Brand            string             `json:"brand" bson:"brand"`
-	Quantity         int                `json:"quantity" bson:"quantity"`
-	Price            float64            `json:"price" bson:"price"`
-	ProductImage_URL string             `json:"productimage_url" bson:"productimage_url"`
-	Description      string             `json:"description" bson:"description"`
-	Created_At       time.Time          `json:"created_at" bson:"created_at"`
-	Updated_At       time.Time          `json:"updated_at" bson:"updated_at"`
+	ID               primitive.ObjectID     `json:"id,omitempty" bson:"_id,omitempty"`
+	SKU              string                 `json:"sku" bson:"sku"`
+	Slug             string                 `json:"slug" bson:"slug"`
+	SlugHistory      []string               `json:"slug_history,omitempty" bson:"slug_history,omitempty"`
+	ProductName      string                 `json:"productname" bson:"productname"`
+	Brand            string                 `json:"brand" bson:"brand"`
+	Quantity         int                    `json:"quantity" bson:"quantity"`
+	Price            money.Money            `json:"price" bson:"price"`
+	PriceOverrides   []money.Money          `json:"price_overrides,omitempty" bson:"price_overrides,omitempty"`
+	Sale             *Sale                  `json:"sale,omitempty" bson:"sale,omitempty"`
+	TaxClass         string                 `json:"tax_class" bson:"tax_class"`
+	ProductImage_URL string                 `json:"productimage_url" bson:"productimage_url"`
+	Description      string                 `json:"description" bson:"description"`
+	Translations     map[string]Translation `json:"translations,omitempty" bson:"translations,omitempty"`
+	Weight           float64                `json:"weight" bson:"weight"`
+	Length           float64                `json:"length" bson:"length"`
+	Width            float64                `json:"width" bson:"width"`
+	Height           float64                `json:"height" bson:"height"`
+	RatingAverage    float64                `json:"rating_average" bson:"rating_average"`
+	RatingCount      int                    `json:"rating_count" bson:"rating_count"`
+	Created_At       time.Time              `json:"created_at" bson:"created_at"`
+	Updated_At       time.Time              `json:"updated_at" bson:"updated_at"`
+	Deleted_At       *time.Time             `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	Version          int64                  `json:"version" bson:"version"`
 }
 
 type ProductResponse struct {
-	ID               string  `json:"_id,omitempty" bson:"_id,omitempty"`
-	ProductName      string  `json:"productname" bson:"productname"`
-	Brand            string  `json:"brand" bson:"brand"`
-	Quantity         int     `json:"quantity" bson:"quantity"`
-	Price            float64 `json:"price" bson:"price"`
-	ProductImage_URL string  `json:"productimage_url" bson:"productimage_url"`
-	Description      string  `json:"description" bson:"description"`
+	ID               string                 `json:"_id,omitempty" bson:"_id,omitempty"`
+	SKU              string                 `json:"sku" bson:"sku"`
+	Slug             string                 `json:"slug" bson:"slug"`
+	ProductName      string                 `json:"productname" bson:"productname"`
+	Brand            string                 `json:"brand" bson:"brand"`
+	Quantity         int                    `json:"quantity" bson:"quantity"`
+	Price            money.Money            `json:"price" bson:"price"`
+	WasPrice         *money.Money           `json:"was_price,omitempty" bson:"-"`
+	SaleEndsAt       *time.Time             `json:"sale_ends_at,omitempty" bson:"-"`
+	PriceOverrides   []money.Money          `json:"price_overrides,omitempty" bson:"price_overrides,omitempty"`
+	TaxClass         string                 `json:"tax_class" bson:"tax_class"`
+	ProductImage_URL string                 `json:"productimage_url" bson:"productimage_url"`
+	Description      string                 `json:"description" bson:"description"`
+	Locale           string                 `json:"locale,omitempty" bson:"-"`
+	Translations     map[string]Translation `json:"-" bson:"-"`
+	Weight           float64                `json:"weight" bson:"weight"`
+	Length           float64                `json:"length" bson:"length"`
+	Width            float64                `json:"width" bson:"width"`
+	Height           float64                `json:"height" bson:"height"`
+	RatingAverage    float64                `json:"rating_average" bson:"rating_average"`
+	RatingCount      int                    `json:"rating_count" bson:"rating_count"`
+	Version          int64                  `json:"version" bson:"version"`
+}
+
+// PriceIn returns the price in currency, preferring a fixed override and
+// otherwise converting the base price with the given rate table.
+func (p ProductResponse) PriceIn(currency string, rates money.RateTable) (money.Money, error) {
+	for _, override := range p.PriceOverrides {
+		if override.Currency == currency {
+			return override, nil
+		}
+	}
+	return rates.Convert(p.Price, currency)
+}
+
+// ConvertTo switches the displayed prices to currency. During a sale the
+// overrides apply to the was price and the sale price is converted.
+func (p *ProductResponse) ConvertTo(currency string, rates money.RateTable) error {
+	if p.WasPrice == nil {
+		price, err := p.PriceIn(currency, rates)
+		if err != nil {
+			return err
+		}
+		p.Price = price
+		return nil
+	}
+	regular := ProductResponse{Price: *p.WasPrice, PriceOverrides: p.PriceOverrides}
+	was, err := regular.PriceIn(currency, rates)
+	if err != nil {
+		return err
+	}
+	now, err := rates.Convert(p.Price, currency)
+	if err != nil {
+		return err
+	}
+	p.Price, p.WasPrice = now, &was
+	return nil
+}
+
+// Localize switches name and description to locale, falling back per field
+// to the default-locale content.
+func (p *ProductResponse) Localize(locale string) {
+	p.Locale = locale
+	if t, ok := p.Translations[locale]; ok {
+		if t.Name != "" {
+			p.ProductName = t.Name
+		}
+		if t.Description != "" {
+			p.Description = t.Description
+		}
+	}
+}
+
+// CurrentPrice is the price charged at t: the sale price while a sale is
+// active and the regular price otherwise.
+func (p Product) CurrentPrice(t time.Time) money.Money {
+	if p.Sale.ActiveAt(t) {
+		return p.Sale.Price
+	}
+	return p.Price
+}
+
+// Response converts a stored product to its API representation. While a
+// sale is active Price holds the sale price and WasPrice the regular one.
+func (p Product) Response() ProductResponse {
+	response := ProductResponse{
+		ID:               p.ID.Hex(),
+		SKU:              p.SKU,
+		Slug:             p.Slug,
+		ProductName:      p.ProductName,
+		Brand:            p.Brand,
+		Quantity:         p.Quantity,
+		Price:            p.Price,
+		PriceOverrides:   p.PriceOverrides,
+		TaxClass:         p.TaxClass,
+		ProductImage_URL: p.ProductImage_URL,
+		Description:      p.Description,
+		Translations:     p.Translations,
+		Weight:           p.Weight,
+		Length:           p.Length,
+		Width:            p.Width,
+		Height:           p.Height,
+		RatingAverage:    p.RatingAverage,
+		RatingCount:      p.RatingCount,
+		Version:          p.Version,
+	}
+	if p.Sale.ActiveAt(time.Now()) {
+		was, ends := p.Price, p.Sale.EndsAt
+		response.Price, response.WasPrice, response.SaleEndsAt = p.Sale.Price, &was, &ends
+	}
+	return response
+}
+
+// ProductForm is the multipart body of a new product. Price is a decimal
+// string in Currency (the default currency when empty) and PriceOverrides a
+// JSON array of money values.
+type ProductForm struct {
+	ProductName    string                `form:"productname" binding:"notblank,max=200"`
+	SKU            string                `form:"sku" binding:"max=64"`
+	Brand          string                `form:"brand" binding:"max=100"`
+	Description    string                `form:"description" binding:"max=5000"`
+	TaxClass       string                `form:"tax_class" binding:"max=50"`
+	Quantity       *int                  `form:"quantity" binding:"required,gte=0"`
+	Price          string                `form:"price" binding:"required"`
+	Currency       string                `form:"currency" binding:"omitempty,len=3"`
+	PriceOverrides string                `form:"price_overrides"`
+	Weight         *float64              `form:"weight" binding:"omitempty,gte=0"`
+	Length         *float64              `form:"length" binding:"omitempty,gte=0"`
+	Width          *float64              `form:"width" binding:"omitempty,gte=0"`
+	Height         *float64              `form:"height" binding:"omitempty,gte=0"`
+	Image          *multipart.FileHeader `form:"image2" binding:"required"`
+}
+
+// ProductUpdateForm is the multipart body of a product update; empty fields
+// are left unchanged. The optional image is read separately.
+type ProductUpdateForm struct {
+	Version        string   `form:"version"`
+	ProductName    string   `form:"productname" binding:"max=200"`
+	SKU            string   `form:"sku" binding:"max=64"`
+	Brand          string   `form:"brand" binding:"max=100"`
+	Description    string   `form:"description" binding:"max=5000"`
+	TaxClass       string   `form:"tax_class" binding:"max=50"`
+	Quantity       *int     `form:"quantity" binding:"omitempty,gte=0"`
+	Price          string   `form:"price"`
+	Currency       string   `form:"currency" binding:"omitempty,len=3"`
+	PriceOverrides string   `form:"price_overrides"`
+	Weight         *float64 `form:"weight" binding:"omitempty,gte=0"`
+	Length         *float64 `form:"length" binding:"omitempty,gte=0"`
+	Width          *float64 `form:"width" binding:"omitempty,gte=0"`
+	Height         *float64 `form:"height" binding:"omitempty,gte=0"`
+}
+
+// ProductPatch holds the product fields a merge patch may change.
+type ProductPatch struct {
+	SKU            string        `json:"sku" binding:"max=64"`
+	ProductName    string        `json:"productname" binding:"notblank,max=200"`
+	Brand          string        `json:"brand" binding:"max=100"`
+	Quantity       int           `json:"quantity" binding:"gte=0"`
+	Price          money.Money   `json:"price"`
+	PriceOverrides []money.Money `json:"price_overrides,omitempty"`
+	TaxClass       string        `json:"tax_class" binding:"max=50"`
+	Description    string        `json:"description" binding:"max=5000"`
+	Weight         float64       `json:"weight" binding:"gte=0"`
+	Length         float64       `json:"length" binding:"gte=0"`
+	Width          float64       `json:"width" binding:"gte=0"`
+	Height         float64       `json:"height" binding:"gte=0"`
+}
+
+// Patchable returns the patchable fields of the product.
+func (p Product) Patchable() ProductPatch {
+	return ProductPatch{
+		SKU:            p.SKU,
+		ProductName:    p.ProductName,
+		Brand:          p.Brand,
+		Quantity:       p.Quantity,
+		Price:          p.Price,
+		PriceOverrides: p.PriceOverrides,
+		TaxClass:       p.TaxClass,
+		Description:    p.Description,
+		Weight:         p.Weight,
+		Length:         p.Length,
+		Width:          p.Width,
+		Height:         p.Height,
+	}
+}
+
+// ApplyPatch copies the patched fields onto the product.
+func (p *Product) ApplyPatch(patch ProductPatch) {
+	p.SKU = patch.SKU
+	p.ProductName = patch.ProductName
+	p.Brand = patch.Brand
+	p.Quantity = patch.Quantity
+	p.Price = patch.Price
+	p.PriceOverrides = patch.PriceOverrides
+	p.TaxClass = patch.TaxClass
+	p.Description = patch.Description
+	p.Weight = patch.Weight
+	p.Length = patch.Length
+	p.Width = patch.Width
+	p.Height = patch.Height
 }
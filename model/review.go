@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReviewPublished = "published"
+	ReviewHidden    = "hidden"
+)
+
+type Review struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	ProductID  primitive.ObjectID `json:"product_id" bson:"product_id"`
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	UserName   string             `json:"user_name" bson:"user_name"`
+	Rating     int                `json:"rating" bson:"rating"`
+	Title      string             `json:"title" bson:"title"`
+	Comment    string             `json:"comment" bson:"comment"`
+	Status     string             `json:"status" bson:"status"`
+	Created_At time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type ReviewRequest struct {
+	ProductID string `json:"product_id" binding:"required,mongodb"`
+	Rating    int    `json:"rating" binding:"required,min=1,max=5"`
+	Title     string `json:"title" binding:"max=200"`
+	Comment   string `json:"comment" binding:"max=5000"`
+}
+
+// ReviewUpdateRequest changes only the non-empty fields.
+type ReviewUpdateRequest struct {
+	Rating  int    `json:"rating" binding:"omitempty,min=1,max=5"`
+	Title   string `json:"title" binding:"max=200"`
+	Comment string `json:"comment" binding:"max=5000"`
+}
+
+type ReviewStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=published hidden"`
+}
+
+type ReviewResponse struct {
+	ID         string    `json:"_id,omitempty"`
+	ProductID  string    `json:"product_id"`
+	UserID     string    `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	Rating     int       `json:"rating"`
+	Title      string    `json:"title"`
+	Comment    string    `json:"comment"`
+	Status     string    `json:"status"`
+	Created_At time.Time `json:"created_at"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// Translation holds the localised text of a product or category for one
+// locale. Empty fields fall back to the default-locale content.
+type Translation struct {
+	Name        string `json:"name" bson:"name" binding:"max=100"`
+	Description string `json:"description" bson:"description" binding:"max=2000"`
+}
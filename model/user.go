@@ -1,14 +1,39 @@
 package model
 
 import (
+	"mime/multipart"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password,omitempty"`
+	Email    string `json:"email" form:"email" binding:"required,email"`
+	Password string `json:"password,omitempty" form:"password" binding:"required"`
+}
+
+// UserForm is the multipart body of a sign-up. Only admins may set Role.
+type UserForm struct {
+	Name     string                `form:"name" binding:"notblank,max=100"`
+	Email    string                `form:"email" binding:"required,email"`
+	Password string                `form:"password" binding:"required,min=8"`
+	Role     string                `form:"role" binding:"omitempty,oneof=customer admin"`
+	Image    *multipart.FileHeader `form:"image" binding:"required"`
+}
+
+// UserUpdateForm is the multipart body of a user update; empty fields are
+// left unchanged. The optional image is read separately.
+type UserUpdateForm struct {
+	Version  string `form:"version"`
+	Name     string `form:"name" binding:"max=100"`
+	Email    string `form:"email" binding:"omitempty,email"`
+	Password string `form:"password" binding:"omitempty,min=8"`
+	Role     string `form:"role" binding:"omitempty,oneof=customer admin"`
 }
 
 type User struct {
@@ -17,14 +42,34 @@ type User struct {
 	Email         string             `bson:"email,unique" json:"email"`
 	Password      string             `bson:"password" json:"password"`
 	UserImage_URL string             `bson:"userimage_url" json:"userimage_url"`
+	Role          string             `bson:"role" json:"role"`
+	Deleted_At    *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	Version       int64              `bson:"version" json:"version"`
 }
 
 type UserResponse struct {
-	Id        string `json:"_id,omitempty" bson:"_id,omitempty"`
-	Name      string `json:"name,omitempty" bson:"name,omitempty"`
-	Email     string `json:"email,omitempty" bson:"email,unique"`
-	Password  string `json:"password,omitempty" bson:"password,omitempty"`
-	Image_URL string `json:"userimage_url,omitempty" bson:"userimage_url,omitempty"`
+	Id         string     `json:"_id,omitempty" bson:"_id,omitempty"`
+	Name       string     `json:"name,omitempty" bson:"name,omitempty"`
+	Email      string     `json:"email,omitempty" bson:"email,unique"`
+	Password   string     `json:"password,omitempty" bson:"password,omitempty"`
+	Image_URL  string     `json:"userimage_url,omitempty" bson:"userimage_url,omitempty"`
+	Role       string     `json:"role,omitempty" bson:"role,omitempty"`
+	Deleted_At *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	Version    int64      `json:"version" bson:"version"`
+}
+
+// Response converts a stored user to its API representation without the
+// password.
+func (u User) Response() UserResponse {
+	return UserResponse{
+		Id:         u.ID.Hex(),
+		Name:       u.Name,
+		Email:      u.Email,
+		Image_URL:  u.UserImage_URL,
+		Role:       u.Role,
+		Deleted_At: u.Deleted_At,
+		Version:    u.Version,
+	}
 }
 
 type Token struct {
@@ -37,3 +82,28 @@ type Token struct {
 	RefreshExpiredAt time.Time          `bson:"refresh_expired_at"`
 	Created_At       time.Time          `bson:"created_at"`
 }
+
+// UserPatch holds the user fields a merge patch may change.
+type UserPatch struct {
+	Name     string `json:"name" binding:"notblank,max=100"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password,omitempty" binding:"omitempty,min=8"`
+	Role     string `json:"role" binding:"omitempty,oneof=customer admin"`
+}
+
+// Patchable returns the patchable fields of the user. The password is left
+// out, so only a password sent in the patch is validated.
+func (u User) Patchable() UserPatch {
+	return UserPatch{Name: u.Name, Email: u.Email, Role: u.Role}
+}
+
+// ApplyPatch copies the patched fields onto the user; an absent password
+// keeps the current one.
+func (u *User) ApplyPatch(patch UserPatch) {
+	u.Name = patch.Name
+	u.Email = patch.Email
+	u.Role = patch.Role
+	if patch.Password != "" {
+		u.Password = patch.Password
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WishlistItem struct {
+	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	ProductID     primitive.ObjectID `json:"product_id" bson:"product_id"`
+	NotifyInStock bool               `json:"notify_in_stock" bson:"notify_in_stock"`
+	Created_At    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type WishlistRequest struct {
+	ProductID     string `json:"product_id" binding:"required,mongodb"`
+	NotifyInStock bool   `json:"notify_in_stock"`
+}
+
+type WishlistResponse struct {
+	Product       ProductResponse `json:"product"`
+	NotifyInStock bool            `json:"notify_in_stock"`
+	Created_At    time.Time       `json:"created_at"`
+}
@@ -0,0 +1,77 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON writes {"amount":"12.50","currency":"USD"}. The amount is a
+// string so clients never round-trip it through a float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+// UnmarshalJSON accepts the amount as a decimal string or a JSON number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	raw := string(v.Amount)
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+	parsed, err := Parse(raw, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+type moneyBSON struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// MarshalBSONValue stores {amount: <minor units>, currency: "USD"}.
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(moneyBSON{Amount: m.Amount, Currency: m.Currency})
+}
+
+// UnmarshalBSONValue also reads legacy numeric prices in DefaultCurrency so
+// documents written before the money migration still decode.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		var v moneyBSON
+		if err := raw.Unmarshal(&v); err != nil {
+			return err
+		}
+		*m = Money{Amount: v.Amount, Currency: v.Currency}
+	case bsontype.Double:
+		*m = FromFloat(raw.Double(), DefaultCurrency)
+	case bsontype.Int32:
+		*m = New(int64(raw.Int32())*pow10(Exponent(DefaultCurrency)), DefaultCurrency)
+	case bsontype.Int64:
+		*m = New(raw.Int64()*pow10(Exponent(DefaultCurrency)), DefaultCurrency)
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("money: cannot decode BSON %s", t)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUnmarshalBSONLegacy(t *testing.T) {
+	defer func(currency string) { DefaultCurrency = currency }(DefaultCurrency)
+	tests := []struct {
+		currency string
+		price    interface{}
+		want     Money
+	}{
+		{"VND", 15000.0, New(15000, "VND")},
+		{"VND", 12.5, New(13, "VND")},
+		{"VND", -12.5, New(-13, "VND")},
+		{"VND", 12.4, New(12, "VND")},
+		{"VND", int32(15000), New(15000, "VND")},
+		{"VND", int64(-15000), New(-15000, "VND")},
+		{"USD", 12.345, New(1235, "USD")},
+		{"USD", 19.99, New(1999, "USD")},
+		{"USD", -0.005, New(-1, "USD")},
+		{"USD", int32(12), New(1200, "USD")},
+		{"KWD", 1.2345, New(1235, "KWD")},
+		{"KWD", int64(3), New(3000, "KWD")},
+		{"USD", bson.M{"amount": int64(1250), "currency": "EUR"}, New(1250, "EUR")},
+		{"USD", nil, Money{}},
+	}
+	for _, tt := range tests {
+		DefaultCurrency = tt.currency
+		data, err := bson.Marshal(bson.M{"price": tt.price})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Price Money `bson:"price"`
+		}
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			t.Errorf("decode %v (%T) in %s: %v", tt.price, tt.price, tt.currency, err)
+			continue
+		}
+		if doc.Price != tt.want {
+			t.Errorf("decode %v (%T) in %s = %v, want %v", tt.price, tt.price, tt.currency, doc.Price, tt.want)
+		}
+	}
+
+	data, _ := bson.Marshal(bson.M{"price": "12.50"})
+	var doc struct {
+		Price Money `bson:"price"`
+	}
+	if err := bson.Unmarshal(data, &doc); err == nil {
+		t.Errorf("decode string price = %v, want an error", doc.Price)
+	}
+}
+
+func TestBSONRoundTrip(t *testing.T) {
+	for _, m := range []Money{New(1250, "USD"), New(-15000, "VND"), New(1005, "KWD")} {
+		data, err := bson.Marshal(struct {
+			Price Money `bson:"price"`
+		}{m})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Price Money `bson:"price"`
+		}
+		if err := bson.Unmarshal(data, &doc); err != nil || doc.Price != m {
+			t.Errorf("round trip of %v = %v, %v", m, doc.Price, err)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(-1250, "USD"))
+	if err != nil || string(data) != `{"amount":"-12.50","currency":"USD"}` {
+		t.Errorf("marshal = %s, %v", data, err)
+	}
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{`{"amount":"12.50","currency":"USD"}`, New(1250, "USD")},
+		{`{"amount":12.5,"currency":"USD"}`, New(1250, "USD")},
+		{`{"amount":"-1.005","currency":"KWD"}`, New(-1005, "KWD")},
+		{`{"amount":15000,"currency":"VND"}`, New(15000, "VND")},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.in), &m); err != nil || m != tt.want {
+			t.Errorf("unmarshal %s = %v, %v; want %v", tt.in, m, err, tt.want)
+		}
+	}
+	var m Money
+	if err := json.Unmarshal([]byte(`{"amount":"12.505","currency":"USD"}`), &m); err == nil {
+		t.Errorf("unmarshal of too many decimals = %v, want an error", m)
+	}
+}
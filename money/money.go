@@ -0,0 +1,295 @@
+// Package money represents amounts as integer minor units of an ISO 4217
+// currency so that totals, tax and refunds never accumulate float error.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+	ErrInvalidCurrency  = errors.New("money: invalid currency code")
+)
+
+// DefaultCurrency is used for amounts that carry no currency, such as legacy
+// float prices and form values without a currency field.
+var DefaultCurrency = "VND"
+
+// exponents lists currencies whose minor unit is not 1/100 of the major unit.
+var exponents = map[string]int{
+	"VND": 0, "JPY": 0, "KRW": 0, "CLP": 0, "ISK": 0, "PYG": 0, "UGX": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent returns the number of minor-unit digits of a currency.
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+type Money struct {
+	Amount   int64  // minor units
+	Currency string // ISO 4217 code
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse reads a decimal string such as "12.50" in the given currency. It
+// refuses more fractional digits than the currency has instead of rounding.
+func Parse(s string, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !ValidCurrency(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	exp := Exponent(currency)
+	if whole == "" && frac == "" || len(frac) > exp || !digits(whole) || !digits(frac) {
+		return Money{}, ErrInvalidAmount
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromFloat converts a legacy float amount, rounding half away from zero to
+// the currency's minor unit.
+func FromFloat(f float64, currency string) Money {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	r.Mul(r, new(big.Rat).SetInt64(pow10(Exponent(currency))))
+	return Money{Amount: roundRat(r), Currency: currency}
+}
+
+// roundRat rounds half away from zero.
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	negative := num.Sign() < 0
+	num.Abs(num)
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if negative {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
+
+// Decimal formats the amount in major units, e.g. "12.50".
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	p := pow10(exp)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/p, exp, amount%p)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+func (m Money) check(o Money) error {
+	// A zero amount without currency is the additive identity for any currency.
+	if m.Currency == o.Currency || o.Currency == "" && o.Amount == 0 || m.Currency == "" && m.Amount == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+func (m Money) currencyWith(o Money) string {
+	if m.Currency == "" {
+		return o.Currency
+	}
+	return m.Currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.check(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.currencyWith(o)}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.check(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.currencyWith(o)}, nil
+}
+
+// Cmp returns -1, 0 or +1 like strings.Compare.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.check(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// MulRat multiplies by an exact ratio, rounding half away from zero.
+func (m Money) MulRat(r *big.Rat) Money {
+	x := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), r)
+	return Money{Amount: roundRat(x), Currency: m.Currency}
+}
+
+// MulRate multiplies by a decimal rate such as a tax rate of 0.1. The rate is
+// taken at its shortest decimal representation, so 0.1 means exactly 1/10.
+func (m Money) MulRate(rate float64) Money {
+	return m.MulRat(Rat(rate))
+}
+
+// Rat converts a float to the exact ratio of its shortest decimal form.
+func Rat(f float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	return r
+}
+
+// RoundTo rounds to a multiple of step minor units, half away from zero,
+// e.g. RoundTo(1000) for cash prices in VND.
+func (m Money) RoundTo(step int64) Money {
+	if step <= 1 {
+		return m
+	}
+	return m.MulRat(big.NewRat(1, step)).Mul(step)
+}
+
+// Allocate splits the amount proportionally to ratios without losing a minor
+// unit; the remainder goes to the first parts, one unit each.
+func (m Money) Allocate(ratios ...int64) []Money {
+	var total int64
+	for _, r := range ratios {
+		total += r
+	}
+	parts := make([]Money, len(ratios))
+	if total == 0 {
+		for i := range parts {
+			parts[i] = Zero(m.Currency)
+		}
+		return parts
+	}
+	remainder := m.Amount
+	for i, r := range ratios {
+		share := m.Amount * r / total
+		parts[i] = Money{Amount: share, Currency: m.Currency}
+		remainder -= share
+	}
+	unit := int64(1)
+	if remainder < 0 {
+		unit = -1
+	}
+	for i := 0; remainder != 0; i++ {
+		parts[i%len(parts)].Amount += unit
+		remainder -= unit
+	}
+	return parts
+}
+
+// Sum adds amounts of one currency.
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Convert changes the currency at rate, the number of target major units per
+// source major unit, rounding half away from zero to the target minor unit.
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	if currency == m.Currency {
+		return m
+	}
+	factor := new(big.Rat).Mul(rate, big.NewRat(pow10(Exponent(currency)), pow10(Exponent(m.Currency))))
+	converted := m.MulRat(factor)
+	converted.Currency = currency
+	return converted
+}
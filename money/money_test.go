@@ -0,0 +1,183 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+		err      error
+	}{
+		{"12.50", "USD", New(1250, "USD"), nil},
+		{"12.5", "usd", New(1250, "USD"), nil},
+		{" 12 ", "USD", New(1200, "USD"), nil},
+		{".5", "USD", New(50, "USD"), nil},
+		{"5.", "USD", New(500, "USD"), nil},
+		{"-12.50", "USD", New(-1250, "USD"), nil},
+		{"-0.01", "USD", New(-1, "USD"), nil},
+		{"15000", "VND", New(15000, "VND"), nil},
+		{"-15000", "VND", New(-15000, "VND"), nil},
+		{"1.234", "KWD", New(1234, "KWD"), nil},
+		{"-1.2", "KWD", New(-1200, "KWD"), nil},
+		{"7", "", New(7, DefaultCurrency), nil},
+		{"12.505", "USD", Money{}, ErrInvalidAmount},
+		{"1.5", "VND", Money{}, ErrInvalidAmount},
+		{"1.2345", "KWD", Money{}, ErrInvalidAmount},
+		{"", "USD", Money{}, ErrInvalidAmount},
+		{"-", "USD", Money{}, ErrInvalidAmount},
+		{"--1", "USD", Money{}, ErrInvalidAmount},
+		{"1e3", "USD", Money{}, ErrInvalidAmount},
+		{"1,000", "USD", Money{}, ErrInvalidAmount},
+		{"99999999999999999999", "USD", Money{}, ErrInvalidAmount},
+		{"1", "US", Money{}, ErrInvalidCurrency},
+		{"1", "U5D", Money{}, ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("Parse(%q, %q) = %v, %v; want %v, %v", tt.in, tt.currency, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{New(1250, "USD"), "12.50"},
+		{New(5, "USD"), "0.05"},
+		{New(-5, "USD"), "-0.05"},
+		{New(-1250, "USD"), "-12.50"},
+		{New(15000, "VND"), "15000"},
+		{New(-15000, "VND"), "-15000"},
+		{New(1005, "KWD"), "1.005"},
+		{New(-1, "KWD"), "-0.001"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMulRat(t *testing.T) {
+	tests := []struct {
+		in   Money
+		r    *big.Rat
+		want int64
+	}{
+		{New(1005, "USD"), big.NewRat(1, 2), 503},
+		{New(-1005, "USD"), big.NewRat(1, 2), -503},
+		{New(1004, "USD"), big.NewRat(1, 2), 502},
+		{New(1000, "USD"), big.NewRat(1, 3), 333},
+		{New(1000, "USD"), big.NewRat(2, 3), 667},
+		{New(-1000, "USD"), big.NewRat(2, 3), -667},
+		{New(15000, "VND"), Rat(0.1), 1500},
+		{New(12345, "KWD"), Rat(0.05), 617},
+		{New(12355, "KWD"), Rat(0.1), 1236},
+		{New(-12355, "KWD"), Rat(0.1), -1236},
+	}
+	for _, tt := range tests {
+		got := tt.in.MulRat(tt.r)
+		if got.Amount != tt.want || got.Currency != tt.in.Currency {
+			t.Errorf("%v.MulRat(%v) = %v, want %d %s", tt.in, tt.r, got, tt.want, tt.in.Currency)
+		}
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		in   int64
+		step int64
+		want int64
+	}{
+		{12500, 1000, 13000},
+		{12499, 1000, 12000},
+		{-12500, 1000, -13000},
+		{-12499, 1000, -12000},
+		{1234, 5, 1235},
+		{1232, 5, 1230},
+		{1234, 1, 1234},
+		{1234, 0, 1234},
+	}
+	for _, tt := range tests {
+		if got := New(tt.in, "VND").RoundTo(tt.step); got.Amount != tt.want {
+			t.Errorf("RoundTo(%d, %d) = %d, want %d", tt.in, tt.step, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		in     Money
+		ratios []int64
+		want   []int64
+	}{
+		{New(100, "USD"), []int64{1, 1}, []int64{50, 50}},
+		{New(100, "USD"), []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{New(101, "USD"), []int64{1, 1, 1}, []int64{34, 34, 33}},
+		{New(-100, "USD"), []int64{1, 1, 1}, []int64{-34, -33, -33}},
+		{New(1000, "VND"), []int64{3, 7}, []int64{300, 700}},
+		{New(1001, "VND"), []int64{1, 2, 3}, []int64{167, 334, 500}},
+		{New(5, "KWD"), []int64{1, 1, 1, 1, 1, 1, 1}, []int64{1, 1, 1, 1, 1, 0, 0}},
+		{New(100, "USD"), []int64{0, 1}, []int64{0, 100}},
+		{New(100, "USD"), []int64{0, 0}, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		parts := tt.in.Allocate(tt.ratios...)
+		if len(parts) != len(tt.want) {
+			t.Fatalf("%v.Allocate(%v) returned %d parts", tt.in, tt.ratios, len(parts))
+		}
+		var sum, ratioSum int64
+		for _, r := range tt.ratios {
+			ratioSum += r
+		}
+		for i, part := range parts {
+			sum += part.Amount
+			if part.Amount != tt.want[i] || part.Currency != tt.in.Currency {
+				t.Errorf("%v.Allocate(%v)[%d] = %v, want %d", tt.in, tt.ratios, i, part, tt.want[i])
+			}
+		}
+		// Parts always add back up to the amount unless every ratio is zero.
+		if ratioSum != 0 && sum != tt.in.Amount {
+			t.Errorf("%v.Allocate(%v) sums to %d", tt.in, tt.ratios, sum)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		in       Money
+		currency string
+		rate     *big.Rat
+		want     Money
+	}{
+		{New(1000, "USD"), "VND", big.NewRat(25000, 1), New(250000, "VND")},
+		{New(25000, "VND"), "USD", big.NewRat(1, 25000), New(100, "USD")},
+		{New(12345, "VND"), "USD", big.NewRat(1, 25000), New(49, "USD")},
+		{New(100, "USD"), "KWD", Rat(0.307), New(307, "KWD")},
+		{New(-100, "USD"), "KWD", Rat(0.3075), New(-308, "KWD")},
+		{New(100, "USD"), "USD", big.NewRat(2, 1), New(100, "USD")},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Convert(tt.currency, tt.rate); got != tt.want {
+			t.Errorf("%v.Convert(%s, %v) = %v, want %v", tt.in, tt.currency, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestAddCurrencies(t *testing.T) {
+	if _, err := New(1, "USD").Add(New(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR error = %v, want ErrCurrencyMismatch", err)
+	}
+	got, err := Money{}.Add(New(-5, "KWD"))
+	if err != nil || got != New(-5, "KWD") {
+		t.Errorf("zero + -5 KWD = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,49 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RateTable quotes every currency against Base: one unit of Base buys
+// Rates[currency] units of that currency.
+type RateTable struct {
+	Base  string
+	Rates map[string]float64
+}
+
+func (t RateTable) rate(currency string) (*big.Rat, error) {
+	if currency == t.Base {
+		return big.NewRat(1, 1), nil
+	}
+	r, ok := t.Rates[currency]
+	if !ok || r <= 0 {
+		return nil, fmt.Errorf("money: no exchange rate for %s", currency)
+	}
+	return Rat(r), nil
+}
+
+// Rate returns how many units of to one unit of from buys, crossing through
+// the base currency.
+func (t RateTable) Rate(from, to string) (*big.Rat, error) {
+	fromRate, err := t.rate(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := t.rate(to)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+func (t RateTable) Convert(m Money, currency string) (Money, error) {
+	if m.Currency == currency {
+		return m, nil
+	}
+	rate, err := t.Rate(m.Currency, currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Convert(currency, rate), nil
+}
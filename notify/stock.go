@@ -0,0 +1,68 @@
+// Package notify alerts users about products they follow.
+package notify
+
+import (
+	"context"
+	"image-server/logging"
+	"image-server/model"
+	"image-server/reponsitory"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockNotifier delivers "back in stock" messages to users.
+type StockNotifier interface {
+	NotifyBackInStock(ctx context.Context, user model.User, product model.Product) error
+}
+
+// LogStockNotifier writes notifications to the log until a mail or push
+// provider is wired in.
+type LogStockNotifier struct{}
+
+func (LogStockNotifier) NotifyBackInStock(ctx context.Context, user model.User, product model.Product) error {
+	logging.FromContext(ctx).Info("back in stock notification", "user_id", user.ID.Hex(), "product_id", product.ID.Hex())
+	return nil
+}
+
+// BackInStock alerts the users who wishlisted a product and asked to be told
+// when it becomes available again.
+type BackInStock struct {
+	WishlistRepo reponsitory.WishlistRepo
+	UserRepo     reponsitory.UserRepo
+	Notifier     StockNotifier
+}
+
+func NewBackInStock(WishlistRepo reponsitory.WishlistRepo, UserRepo reponsitory.UserRepo) *BackInStock {
+	return &BackInStock{
+		WishlistRepo: WishlistRepo,
+		UserRepo:     UserRepo,
+		Notifier:     LogStockNotifier{},
+	}
+}
+
+// Notify alerts every subscriber of product. Each subscription fires once
+// and is then cleared; failures are logged.
+func (b *BackInStock) Notify(ctx context.Context, product model.Product) {
+	logger := logging.FromContext(ctx).With("product_id", product.ID.Hex())
+	items, err := b.WishlistRepo.GetStockSubscribers(ctx, product.ID)
+	if err != nil {
+		logger.Error("load back in stock subscribers", "error", err)
+		return
+	}
+	var notified []primitive.ObjectID
+	for _, item := range items {
+		user, err := b.UserRepo.GetByID(ctx, item.UserID)
+		if err != nil {
+			logger.Error("load subscriber", "user_id", item.UserID.Hex(), "error", err)
+			continue
+		}
+		if err := b.Notifier.NotifyBackInStock(ctx, user, product); err != nil {
+			logger.Error("notify subscriber", "user_id", item.UserID.Hex(), "error", err)
+			continue
+		}
+		notified = append(notified, item.ID)
+	}
+	if err := b.WishlistRepo.ClearStockNotification(ctx, notified); err != nil {
+		logger.Error("clear back in stock subscriptions", "error", err)
+	}
+}
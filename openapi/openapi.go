@@ -0,0 +1,289 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document built from
+// the routes registered on the router, and serves it with a docs UI.
+package openapi
+
+import (
+	"fmt"
+	"image-server/apierr"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of the generated document.
+const Version = "3.0.3"
+
+// Auth is the authentication an endpoint requires.
+type Auth int
+
+const (
+	Public Auth = iota
+	// User requires a bearer token.
+	User
+	// Admin requires a bearer token of an admin.
+	Admin
+)
+
+// Endpoint describes a route; the path, path parameters and operation ID
+// come from the route itself.
+type Endpoint struct {
+	Tag         string
+	Summary     string
+	Description string
+	Auth        Auth
+	Params      []Parameter
+	// JSON, Form and Patch are sample values of the request body sent as
+	// JSON, as a form or as a JSON Merge Patch.
+	JSON  interface{}
+	Form  interface{}
+	Patch interface{}
+	// Consumes lists raw request body media types.
+	Consumes []string
+	// Response is a sample value of the JSON success response; Object
+	// describes gin.H envelopes.
+	Response interface{}
+	// Produces lists the media types of a non-JSON success response.
+	Produces []string
+}
+
+// Object describes a JSON object by sample values of its members.
+type Object map[string]interface{}
+
+// Query is an optional query parameter.
+func Query(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Header is an optional request header.
+func Header(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	types map[string]reflect.Type
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+const bearerAuth = "bearerAuth"
+
+// New returns a document without operations.
+func New(title, version string) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]*PathItem{},
+		types:   map[string]reflect.Type{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	d.Components.Schemas["Problem"] = d.schemas().of(reflect.TypeOf(apierr.Problem{}), "json", true)
+	return d
+}
+
+func (d *Document) schemas() *generator {
+	return &generator{components: d.Components.Schemas, types: d.types}
+}
+
+// Describe adds an operation for every route, documented by the endpoint
+// keyed "METHOD /path". It fails when a route is undocumented or an
+// endpoint matches no route, so the document cannot drift from the router.
+func (d *Document) Describe(routes gin.RoutesInfo, endpoints map[string]Endpoint) error {
+	var problems []string
+	seen := map[string]bool{}
+	ids := map[string]bool{}
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		seen[key] = true
+		endpoint, ok := endpoints[key]
+		if !ok {
+			problems = append(problems, "route "+key+" is not documented")
+			continue
+		}
+		path, params := pathTemplate(route.Path)
+		item := d.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			d.Paths[path] = item
+		}
+		op := d.operation(endpoint, params)
+		if id := operationID(route.Handler); id != "" && !ids[id] {
+			op.OperationID, ids[id] = id, true
+		}
+		(*item)[strings.ToLower(route.Method)] = op
+	}
+	for key := range endpoints {
+		if !seen[key] {
+			problems = append(problems, "endpoint "+key+" has no route")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (d *Document) operation(endpoint Endpoint, params []Parameter) *Operation {
+	gen := d.schemas()
+	op := &Operation{
+		Summary:     endpoint.Summary,
+		Description: endpoint.Description,
+		Parameters:  append(params, endpoint.Params...),
+		Responses: map[string]*Response{
+			"default": {
+				Description: "Error",
+				Content:     map[string]MediaType{apierr.ContentType: {Schema: ref("Problem")}},
+			},
+		},
+	}
+	if endpoint.Tag != "" {
+		op.Tags = []string{endpoint.Tag}
+	}
+	switch endpoint.Auth {
+	case User:
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	case Admin:
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+		op.Description = strings.TrimSpace(op.Description + "\n\nRequires the admin role.")
+	}
+
+	content := map[string]MediaType{}
+	if endpoint.JSON != nil {
+		content["application/json"] = MediaType{Schema: gen.body(endpoint.JSON, "json", false)}
+	}
+	if endpoint.Patch != nil {
+		schema := gen.body(endpoint.Patch, "json", true)
+		schema.Properties["version"] = &Schema{Type: "integer", Description: "Expected version, instead of If-Match"}
+		content["application/merge-patch+json"] = MediaType{Schema: schema}
+		content["application/json"] = MediaType{Schema: schema}
+	}
+	if endpoint.Form != nil {
+		schema := gen.body(endpoint.Form, "form", false)
+		content["multipart/form-data"] = MediaType{Schema: schema}
+		if !hasFile(schema) {
+			content["application/x-www-form-urlencoded"] = MediaType{Schema: schema}
+		}
+	}
+	for _, mediaType := range endpoint.Consumes {
+		content[mediaType] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	if len(content) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: content}
+	}
+
+	success := &Response{Description: "OK", Content: map[string]MediaType{}}
+	if endpoint.Response != nil {
+		success.Content["application/json"] = MediaType{Schema: gen.value(endpoint.Response)}
+	}
+	for _, mediaType := range endpoint.Produces {
+		success.Content[mediaType] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	op.Responses["200"] = success
+	return op
+}
+
+// pathTemplate turns a gin path such as /api/product/get/:id into
+// /api/product/get/{id} and returns its path parameters.
+func pathTemplate(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID is the method name of a handler such as
+// image-server/controller.(*ProductController).CreateProduct-fm, or "" for
+// anonymous functions.
+func operationID(handler string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	if strings.HasPrefix(name, "func") || !isIdentifier(name) {
+		return ""
+	}
+	return name
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// ServeJSON writes the document.
+func (d *Document) ServeJSON(c *gin.Context) {
+	c.JSON(http.StatusOK, d)
+}
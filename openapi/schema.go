@@ -0,0 +1,256 @@
+package openapi
+
+import (
+	"image-server/money"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schema is the subset of the OpenAPI schema object the generator emits.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// known are types whose JSON form differs from their Go structure.
+var known = map[reflect.Type]func() *Schema{
+	reflect.TypeOf(time.Time{}): func() *Schema {
+		return &Schema{Type: "string", Format: "date-time"}
+	},
+	reflect.TypeOf(primitive.ObjectID{}): func() *Schema {
+		return &Schema{Type: "string", Pattern: "^[0-9a-f]{24}$"}
+	},
+	reflect.TypeOf(multipart.FileHeader{}): func() *Schema {
+		return &Schema{Type: "string", Format: "binary"}
+	},
+	reflect.TypeOf(money.Money{}): func() *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"amount":   {Type: "string", Description: "Decimal amount, such as 12.50"},
+				"currency": {Type: "string", Description: "ISO 4217 code", Pattern: "^[A-Z]{3}$"},
+			},
+			Required: []string{"amount", "currency"},
+		}
+	},
+}
+
+// generator derives schemas from Go types. Named structs are added to
+// components and referenced.
+type generator struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+// value describes a sample response value.
+func (g *generator) value(v interface{}) *Schema {
+	if object, ok := v.(Object); ok {
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, member := range object {
+			schema.Properties[name] = g.value(member)
+		}
+		return schema
+	}
+	return g.of(reflect.TypeOf(v), "json", false)
+}
+
+// body describes a request body. The top-level object is inlined; optional
+// drops its required members, as for merge patches.
+func (g *generator) body(v interface{}, tag string, optional bool) *Schema {
+	schema := g.object(indirect(reflect.TypeOf(v)), tag)
+	if optional {
+		schema.Required = nil
+	}
+	return schema
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// of describes t; inline skips the components lookup for t itself.
+func (g *generator) of(t reflect.Type, tag string, inline bool) *Schema {
+	t = indirect(t)
+	if schema, ok := known[t]; ok {
+		return schema()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.of(t.Elem(), tag, false)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.of(t.Elem(), tag, false)}
+	case reflect.Struct:
+		if inline || t.Name() == "" || tag != "json" {
+			return g.object(t, tag)
+		}
+		return ref(g.component(t))
+	}
+	return &Schema{}
+}
+
+// component registers the schema of the named struct t and returns its
+// name, qualified by package when two types share a name.
+func (g *generator) component(t reflect.Type) string {
+	name := t.Name()
+	if other, taken := g.types[name]; taken && other != t {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	if _, ok := g.types[name]; !ok {
+		// Register before describing the fields so recursive types end.
+		g.types[name] = t
+		schema := &Schema{}
+		g.components[name] = schema
+		*schema = *g.object(t, "json")
+	}
+	return name
+}
+
+// object describes the fields of struct t named by tag ("json" or "form"),
+// applying their binding rules.
+func (g *generator) object(t reflect.Type, tag string) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			embedded := g.object(indirect(field.Type), tag)
+			for n, s := range embedded.Properties {
+				schema.Properties[n] = s
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.of(field.Type, tag, false)
+		if applyBinding(property, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// applyBinding copies validator rules onto s and reports whether the field
+// is required. Rules after dive apply to elements and are skipped.
+func applyBinding(s *Schema, rules string) bool {
+	if rules == "" || s.Ref != "" {
+		return strings.Contains(rules, "required")
+	}
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		n, _ := strconv.Atoi(param)
+		f, _ := strconv.ParseFloat(param, 64)
+		switch name {
+		case "dive":
+			return required
+		case "required", "notblank":
+			required = true
+			if s.Type == "string" && s.Format != "binary" && s.MinLength == nil {
+				one := 1
+				s.MinLength = &one
+			}
+		case "email":
+			s.Format = "email"
+		case "mongodb":
+			s.Pattern = "^[0-9a-f]{24}$"
+		case "alpha":
+			s.Pattern = "^[A-Za-z]*$"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "len":
+			s.bound(n, f, true, true)
+		case "min", "gte":
+			s.bound(n, f, true, false)
+		case "max", "lte":
+			s.bound(n, f, false, true)
+		case "gt":
+			s.Minimum, s.ExclusiveMinimum = &f, true
+		}
+	}
+	return required
+}
+
+// bound sets the lower and/or upper limit matching the schema type.
+func (s *Schema) bound(n int, f float64, lower, upper bool) {
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &n
+		}
+		if upper {
+			s.MaxLength = &n
+		}
+	case "array":
+		if lower {
+			s.MinItems = &n
+		}
+		if upper {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &f
+		}
+		if upper {
+			s.Maximum = &f
+		}
+	}
+}
+
+func hasFile(s *Schema) bool {
+	for _, property := range s.Properties {
+		if property.Format == "binary" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// docsPage renders the document served at /openapi.json with Swagger UI.
+// The Swagger UI script and stylesheet are loaded from cdn.jsdelivr.net, so
+// the page needs the browser to reach the internet; /openapi.json itself is
+// served by the API and works offline.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// ServeDocs writes the docs UI page.
+func ServeDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
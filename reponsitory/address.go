@@ -0,0 +1,161 @@
+package reponsitory
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AddressRepo interface {
+	GetByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Address, error)
+	FindForUser(ctx context.Context, userID primitive.ObjectID, id string) (model.Address, error)
+	FindDefault(ctx context.Context, userID primitive.ObjectID, billing bool) (model.Address, error)
+	Count(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	Create(ctx context.Context, address model.Address) (model.Address, error)
+	Update(ctx context.Context, address model.Address) (model.Address, error)
+	Delete(ctx context.Context, userID primitive.ObjectID, id string) error
+}
+
+type AddressRepoI struct {
+	DB *mongo.Database
+}
+
+func NewAddressRepo(DB *mongo.Database) AddressRepo {
+	return &AddressRepoI{DB: DB}
+}
+
+func (a *AddressRepoI) GetByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Address, error) {
+	ctx, span := tracing.Start(ctx, "AddressRepo.GetByUser")
+	defer span.End()
+	addresses := []model.Address{}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	result, err := a.DB.Collection("addresses").Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// FindForUser loads an address only if it belongs to the given user, so
+// checkout can safely reference an address by ID.
+func (a *AddressRepoI) FindForUser(ctx context.Context, userID primitive.ObjectID, id string) (model.Address, error) {
+	ctx, span := tracing.Start(ctx, "AddressRepo.FindForUser")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Address{}, err
+	}
+	var address model.Address
+	err = a.DB.Collection("addresses").FindOne(ctx, bson.M{"_id": objID, "user_id": userID}).Decode(&address)
+	if err != nil {
+		return model.Address{}, err
+	}
+	return address, nil
+}
+
+func (a *AddressRepoI) FindDefault(ctx context.Context, userID primitive.ObjectID, billing bool) (model.Address, error) {
+	ctx, span := tracing.Start(ctx, "AddressRepo.FindDefault")
+	defer span.End()
+	field := "is_default_shipping"
+	if billing {
+		field = "is_default_billing"
+	}
+	var address model.Address
+	err := a.DB.Collection("addresses").FindOne(ctx, bson.M{"user_id": userID, field: true}).Decode(&address)
+	if err != nil {
+		return model.Address{}, err
+	}
+	return address, nil
+}
+
+func (a *AddressRepoI) Count(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	ctx, span := tracing.Start(ctx, "AddressRepo.Count")
+	defer span.End()
+	return a.DB.Collection("addresses").CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// clearDefaults unsets the default flags the address is about to take over.
+func (a *AddressRepoI) clearDefaults(ctx context.Context, address model.Address) error {
+	unset := bson.M{}
+	if address.IsDefaultShipping {
+		unset["is_default_shipping"] = false
+	}
+	if address.IsDefaultBilling {
+		unset["is_default_billing"] = false
+	}
+	if len(unset) == 0 {
+		return nil
+	}
+	_, err := a.DB.Collection("addresses").UpdateMany(ctx, bson.M{"user_id": address.UserID, "_id": bson.M{"$ne": address.ID}}, bson.M{
+		"$set": unset,
+	})
+	return err
+}
+
+func (a *AddressRepoI) Create(ctx context.Context, address model.Address) (model.Address, error) {
+	ctx, span := tracing.Start(ctx, "AddressRepo.Create")
+	defer span.End()
+	address.ID = primitive.NewObjectID()
+	if err := a.clearDefaults(ctx, address); err != nil {
+		return model.Address{}, err
+	}
+	if _, err := a.DB.Collection("addresses").InsertOne(ctx, address); err != nil {
+		return model.Address{}, err
+	}
+	return address, nil
+}
+
+func (a *AddressRepoI) Update(ctx context.Context, address model.Address) (model.Address, error) {
+	ctx, span := tracing.Start(ctx, "AddressRepo.Update")
+	defer span.End()
+	if err := a.clearDefaults(ctx, address); err != nil {
+		return model.Address{}, err
+	}
+	result, err := a.DB.Collection("addresses").UpdateOne(ctx, bson.M{"_id": address.ID, "user_id": address.UserID}, bson.M{
+		"$set": bson.M{
+			"label":               address.Label,
+			"full_name":           address.FullName,
+			"phone":               address.Phone,
+			"line1":               address.Line1,
+			"line2":               address.Line2,
+			"city":                address.City,
+			"region":              address.Region,
+			"postal_code":         address.PostalCode,
+			"country":             address.Country,
+			"is_default_shipping": address.IsDefaultShipping,
+			"is_default_billing":  address.IsDefaultBilling,
+			"updated_at":          address.Updated_At,
+		}})
+	if err != nil {
+		return model.Address{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.Address{}, mongo.ErrNoDocuments
+	}
+	return address, nil
+}
+
+func (a *AddressRepoI) Delete(ctx context.Context, userID primitive.ObjectID, id string) error {
+	ctx, span := tracing.Start(ctx, "AddressRepo.Delete")
+	defer span.End()
+	ID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := a.DB.Collection("addresses").DeleteOne(ctx, bson.M{"_id": ID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
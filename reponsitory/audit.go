@@ -0,0 +1,78 @@
+package reponsitory
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepo interface {
+	Create(ctx context.Context, entry model.AuditEntry) (model.AuditEntry, error)
+	Find(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+}
+
+type AuditRepoI struct {
+	DB *mongo.Database
+}
+
+func NewAuditRepo(DB *mongo.Database) AuditRepo {
+	return &AuditRepoI{DB: DB}
+}
+
+func (a *AuditRepoI) Create(ctx context.Context, entry model.AuditEntry) (model.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditRepo.Create")
+	defer span.End()
+	result, err := a.DB.Collection("audit_log").InsertOne(ctx, entry)
+	if err != nil {
+		return model.AuditEntry{}, err
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return entry, nil
+}
+
+// Find returns matching entries, newest first.
+func (a *AuditRepoI) Find(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditRepo.Find")
+	defer span.End()
+	query := bson.M{}
+	if filter.Entity != "" {
+		query["entity"] = filter.Entity
+	}
+	if filter.EntityID != "" {
+		query["entity_id"] = filter.EntityID
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	created := bson.M{}
+	if !filter.From.IsZero() {
+		created["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		created["$lt"] = filter.To
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	entries := []model.AuditEntry{}
+	cursor, err := a.DB.Collection("audit_log").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,143 @@
+package reponsitory
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CategoryRepo interface {
+	FindByID(ctx context.Context, id string) (model.Category, error)
+	GetAll(ctx context.Context) ([]model.Category, error)
+	Create(ctx context.Context, category model.Category) (model.Category, error)
+	Update(ctx context.Context, category model.Category) (model.Category, error)
+	Delete(ctx context.Context, id string) error
+	SetTranslation(ctx context.Context, id string, locale string, translation model.Translation) error
+	DeleteTranslation(ctx context.Context, id string, locale string) error
+}
+
+type CategoryRepoI struct {
+	DB *mongo.Database
+}
+
+func NewCategoryRepo(DB *mongo.Database) CategoryRepo {
+	return &CategoryRepoI{DB: DB}
+}
+
+func (r *CategoryRepoI) FindByID(ctx context.Context, id string) (model.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.FindByID")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Category{}, err
+	}
+	var category model.Category
+	err = r.DB.Collection("categories").FindOne(ctx, bson.M{"_id": objID}).Decode(&category)
+	if err != nil {
+		return model.Category{}, err
+	}
+	return category, nil
+}
+
+func (r *CategoryRepoI) GetAll(ctx context.Context) ([]model.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.GetAll")
+	defer span.End()
+	categories := []model.Category{}
+	result, err := r.DB.Collection("categories").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoryRepoI) Create(ctx context.Context, category model.Category) (model.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.Create")
+	defer span.End()
+	result, err := r.DB.Collection("categories").InsertOne(ctx, category)
+	if err != nil {
+		return model.Category{}, err
+	}
+	category.ID = result.InsertedID.(primitive.ObjectID)
+	return category, nil
+}
+
+func (r *CategoryRepoI) Update(ctx context.Context, category model.Category) (model.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.Update")
+	defer span.End()
+	result, err := r.DB.Collection("categories").UpdateOne(ctx, bson.M{"_id": category.ID}, bson.M{
+		"$set": bson.M{
+			"name":        category.Name,
+			"description": category.Description,
+			"updated_at":  category.Updated_At,
+		}})
+	if err != nil {
+		return model.Category{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.Category{}, mongo.ErrNoDocuments
+	}
+	return category, nil
+}
+
+func (r *CategoryRepoI) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.Delete")
+	defer span.End()
+	ID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := r.DB.Collection("categories").DeleteOne(ctx, bson.M{"_id": ID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *CategoryRepoI) SetTranslation(ctx context.Context, id string, locale string, translation model.Translation) error {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.SetTranslation")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := r.DB.Collection("categories").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"translations." + locale: translation},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *CategoryRepoI) DeleteTranslation(ctx context.Context, id string, locale string) error {
+	ctx, span := tracing.Start(ctx, "CategoryRepo.DeleteTranslation")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := r.DB.Collection("categories").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$unset": bson.M{"translations." + locale: ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
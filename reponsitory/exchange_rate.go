@@ -0,0 +1,65 @@
+package reponsitory
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExchangeRateRepo interface {
+	Latest(ctx context.Context) (model.ExchangeRates, error)
+	FindByID(ctx context.Context, id string) (model.ExchangeRates, error)
+	Create(ctx context.Context, rates model.ExchangeRates) (model.ExchangeRates, error)
+}
+
+type ExchangeRateRepoI struct {
+	DB *mongo.Database
+}
+
+func NewExchangeRateRepo(DB *mongo.Database) ExchangeRateRepo {
+	return &ExchangeRateRepoI{DB: DB}
+}
+
+// Latest returns the most recent rate snapshot.
+func (e *ExchangeRateRepoI) Latest(ctx context.Context) (model.ExchangeRates, error) {
+	ctx, span := tracing.Start(ctx, "ExchangeRateRepo.Latest")
+	defer span.End()
+	var rates model.ExchangeRates
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	err := e.DB.Collection("exchange_rates").FindOne(ctx, bson.M{}, opts).Decode(&rates)
+	if err != nil {
+		return model.ExchangeRates{}, err
+	}
+	return rates, nil
+}
+
+func (e *ExchangeRateRepoI) FindByID(ctx context.Context, id string) (model.ExchangeRates, error) {
+	ctx, span := tracing.Start(ctx, "ExchangeRateRepo.FindByID")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.ExchangeRates{}, err
+	}
+	var rates model.ExchangeRates
+	err = e.DB.Collection("exchange_rates").FindOne(ctx, bson.M{"_id": objID}).Decode(&rates)
+	if err != nil {
+		return model.ExchangeRates{}, err
+	}
+	return rates, nil
+}
+
+func (e *ExchangeRateRepoI) Create(ctx context.Context, rates model.ExchangeRates) (model.ExchangeRates, error) {
+	ctx, span := tracing.Start(ctx, "ExchangeRateRepo.Create")
+	defer span.End()
+	result, err := e.DB.Collection("exchange_rates").InsertOne(ctx, rates)
+	if err != nil {
+		return model.ExchangeRates{}, err
+	}
+	rates.ID = result.InsertedID.(primitive.ObjectID)
+	return rates, nil
+}
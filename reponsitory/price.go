@@ -0,0 +1,182 @@
+package reponsitory
+
+import (
+	"context"
+	"errors"
+	"image-server/model"
+	"image-server/tracing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrSaleOverlap = errors.New("sale overlaps another sale of this product")
+
+// ScheduleLease is how long a claimed schedule stays with the job that
+// claimed it. A claim older than that was left by a job that died and is
+// due again.
+const ScheduleLease = 5 * time.Minute
+
+type PriceRepo interface {
+	RecordChange(ctx context.Context, entry model.PriceHistory) error
+	History(ctx context.Context, productID primitive.ObjectID) ([]model.PriceHistory, error)
+	CreateSchedule(ctx context.Context, schedule model.PriceSchedule) (model.PriceSchedule, error)
+	Schedules(ctx context.Context, productID primitive.ObjectID) ([]model.PriceSchedule, error)
+	Due(ctx context.Context, now time.Time) ([]model.PriceSchedule, error)
+	ClaimSchedule(ctx context.Context, schedule model.PriceSchedule, now time.Time) (bool, error)
+	SetScheduleStatus(ctx context.Context, id primitive.ObjectID, status string) error
+	CancelSchedule(ctx context.Context, id string) (model.PriceSchedule, error)
+}
+
+type PriceRepoI struct {
+	DB *mongo.Database
+}
+
+func NewPriceRepo(DB *mongo.Database) PriceRepo {
+	return &PriceRepoI{DB: DB}
+}
+
+func (p *PriceRepoI) RecordChange(ctx context.Context, entry model.PriceHistory) error {
+	ctx, span := tracing.Start(ctx, "PriceRepo.RecordChange")
+	defer span.End()
+	if entry.Changed_At.IsZero() {
+		entry.Changed_At = time.Now()
+	}
+	_, err := p.DB.Collection("price_history").InsertOne(ctx, entry)
+	return err
+}
+
+// History returns the price changes of a product, newest first.
+func (p *PriceRepoI) History(ctx context.Context, productID primitive.ObjectID) ([]model.PriceHistory, error) {
+	ctx, span := tracing.Start(ctx, "PriceRepo.History")
+	defer span.End()
+	history := []model.PriceHistory{}
+	opts := options.Find().SetSort(bson.M{"changed_at": -1})
+	result, err := p.DB.Collection("price_history").Find(ctx, bson.M{"product_id": productID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// CreateSchedule stores a pending change. Sales of one product may not
+// overlap, so at most one sale price applies at any time.
+func (p *PriceRepoI) CreateSchedule(ctx context.Context, schedule model.PriceSchedule) (model.PriceSchedule, error) {
+	ctx, span := tracing.Start(ctx, "PriceRepo.CreateSchedule")
+	defer span.End()
+	if schedule.Kind == model.SaleKind {
+		count, err := p.DB.Collection("price_schedules").CountDocuments(ctx, bson.M{
+			"product_id": schedule.ProductID,
+			"kind":       model.SaleKind,
+			"status":     bson.M{"$in": []string{model.ScheduleScheduled, model.ScheduleActive}},
+			"starts_at":  bson.M{"$lt": schedule.EndsAt},
+			"ends_at":    bson.M{"$gt": schedule.StartsAt},
+		})
+		if err != nil {
+			return model.PriceSchedule{}, err
+		}
+		if count > 0 {
+			return model.PriceSchedule{}, ErrSaleOverlap
+		}
+	}
+	result, err := p.DB.Collection("price_schedules").InsertOne(ctx, schedule)
+	if err != nil {
+		return model.PriceSchedule{}, err
+	}
+	schedule.ID = result.InsertedID.(primitive.ObjectID)
+	return schedule, nil
+}
+
+// Schedules returns every schedule of a product in start order.
+func (p *PriceRepoI) Schedules(ctx context.Context, productID primitive.ObjectID) ([]model.PriceSchedule, error) {
+	ctx, span := tracing.Start(ctx, "PriceRepo.Schedules")
+	defer span.End()
+	return p.findSchedules(ctx, bson.M{"product_id": productID})
+}
+
+// Due returns the schedules that must start or, for running sales, end by
+// now, along with those whose claim outlived ScheduleLease.
+func (p *PriceRepoI) Due(ctx context.Context, now time.Time) ([]model.PriceSchedule, error) {
+	ctx, span := tracing.Start(ctx, "PriceRepo.Due")
+	defer span.End()
+	return p.findSchedules(ctx, bson.M{"$or": []bson.M{
+		{"status": model.ScheduleScheduled, "starts_at": bson.M{"$lte": now}},
+		{"status": model.ScheduleActive, "ends_at": bson.M{"$lte": now}},
+		{"status": model.ScheduleApplying, "claimed_at": bson.M{"$lte": now.Add(-ScheduleLease)}},
+	}})
+}
+
+func (p *PriceRepoI) findSchedules(ctx context.Context, filter bson.M) ([]model.PriceSchedule, error) {
+	schedules := []model.PriceSchedule{}
+	opts := options.Find().SetSort(bson.M{"starts_at": 1})
+	result, err := p.DB.Collection("price_schedules").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ClaimSchedule marks a due schedule as applying at now, unless it changed
+// since it was loaded, and reports whether this caller claimed it. Only one
+// of several concurrent jobs gets to apply a schedule; an expired claim is
+// taken over with the status it was claimed from.
+func (p *PriceRepoI) ClaimSchedule(ctx context.Context, schedule model.PriceSchedule, now time.Time) (bool, error) {
+	ctx, span := tracing.Start(ctx, "PriceRepo.ClaimSchedule")
+	defer span.End()
+	filter := bson.M{"_id": schedule.ID, "status": schedule.Status}
+	set := bson.M{"status": model.ScheduleApplying, "claimed_from": schedule.Status, "claimed_at": now}
+	if schedule.Status == model.ScheduleApplying {
+		filter["claimed_at"] = schedule.Claimed_At
+		set = bson.M{"claimed_at": now}
+	}
+	err := p.DB.Collection("price_schedules").FindOneAndUpdate(ctx, filter, bson.M{"$set": set}).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *PriceRepoI) SetScheduleStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+	ctx, span := tracing.Start(ctx, "PriceRepo.SetScheduleStatus")
+	defer span.End()
+	result, err := p.DB.Collection("price_schedules").UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": status},
+		"$unset": bson.M{"claimed_from": "", "claimed_at": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// CancelSchedule cancels a pending change or a running sale and returns it
+// as it was before cancelling.
+func (p *PriceRepoI) CancelSchedule(ctx context.Context, id string) (model.PriceSchedule, error) {
+	ctx, span := tracing.Start(ctx, "PriceRepo.CancelSchedule")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.PriceSchedule{}, err
+	}
+	var schedule model.PriceSchedule
+	err = p.DB.Collection("price_schedules").FindOneAndUpdate(ctx, bson.M{
+		"_id":    objID,
+		"status": bson.M{"$in": []string{model.ScheduleScheduled, model.ScheduleActive}},
+	}, bson.M{"$set": bson.M{"status": model.ScheduleCancelled}}).Decode(&schedule)
+	if err != nil {
+		return model.PriceSchedule{}, err
+	}
+	return schedule, nil
+}
@@ -2,19 +2,40 @@ package reponsitory
 
 import (
 	"context"
+	"fmt"
 	"image-server/model"
+	"image-server/money"
+	"image-server/slug"
+	"image-server/tracing"
+	"math"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductRepo interface {
 	FindByID(ctx context.Context, id string) (model.Product, error)
+	FindBySKU(ctx context.Context, sku string) (model.Product, error)
+	FindBySlug(ctx context.Context, slug string) (model.Product, error)
+	RefreshSlug(ctx context.Context, product *model.Product) error
 	GetAll(ctx context.Context) ([]model.ProductResponse, error)
+	Each(ctx context.Context, fn func(model.Product) error) error
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.ProductResponse, error)
 	Create(ctx context.Context, product model.Product) (model.Product, error)
 	Update(ctx context.Context, product model.Product) (model.Product, error)
+	Patch(ctx context.Context, before, after model.Product) (model.Product, error)
 	Delete(ctx context.Context, id string) error
+	GetDeleted(ctx context.Context) ([]model.Product, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) ([]primitive.ObjectID, error)
+	UpdateRating(ctx context.Context, id primitive.ObjectID, average float64, count int) error
+	SetPrice(ctx context.Context, id primitive.ObjectID, price money.Money) error
+	SetSale(ctx context.Context, id primitive.ObjectID, sale *model.Sale) error
+	SetTranslation(ctx context.Context, id string, locale string, translation model.Translation) error
+	DeleteTranslation(ctx context.Context, id string, locale string) error
 }
 
 type ProductRepoI struct {
@@ -26,9 +47,11 @@ func NewProductRepo(DB *mongo.Database) ProductRepo {
 }
 
 func (p *ProductRepoI) GetAll(ctx context.Context) ([]model.ProductResponse, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.GetAll")
+	defer span.End()
 	var products []model.ProductResponse
 	var items []model.Product
-	result, err := p.DB.Collection("products").Find(ctx, bson.M{})
+	result, err := p.DB.Collection("products").Find(ctx, bson.M{"deleted_at": nil})
 	if err != nil {
 		return nil, err
 	}
@@ -36,33 +59,151 @@ func (p *ProductRepoI) GetAll(ctx context.Context) ([]model.ProductResponse, err
 		return nil, err
 	}
 	for _, item := range items {
-		products = append(products, model.ProductResponse{
-			ID:               item.ID.Hex(),
-			ProductName:      item.ProductName,
-			Brand:            item.Brand,
-			Quantity:         item.Quantity,
-			Price:            item.Price,
-			ProductImage_URL: item.ProductImage_URL,
-			Description:      item.Description,
-		})
+		products = append(products, item.Response())
 	}
 	return products, nil
 }
 
+// FindByIDs returns the products that still exist among ids, keyed by ID.
+func (p *ProductRepoI) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.ProductResponse, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.FindByIDs")
+	defer span.End()
+	products := make(map[primitive.ObjectID]model.ProductResponse)
+	if len(ids) == 0 {
+		return products, nil
+	}
+	var items []model.Product
+	result, err := p.DB.Collection("products").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		products[item.ID] = item.Response()
+	}
+	return products, nil
+}
+
+// Each streams every product to fn in _id order without loading the whole
+// collection, stopping at the first error fn returns.
+func (p *ProductRepoI) Each(ctx context.Context, fn func(model.Product) error) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.Each")
+	defer span.End()
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetBatchSize(200)
+	cursor, err := p.DB.Collection("products").Find(ctx, bson.M{"deleted_at": nil}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var product model.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (p *ProductRepoI) FindByID(ctx context.Context, id string) (model.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.FindByID")
+	defer span.End()
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return model.Product{}, err
 	}
 	var product model.Product
-	err = p.DB.Collection("products").FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
+	err = p.DB.Collection("products").FindOne(ctx, bson.M{"_id": objID, "deleted_at": nil}).Decode(&product)
 	if err != nil {
 		return model.Product{}, err
 	}
 	return product, nil
 }
 
+// FindBySKU also returns soft-deleted products because SKUs stay reserved
+// until the product is purged.
+func (p *ProductRepoI) FindBySKU(ctx context.Context, sku string) (model.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.FindBySKU")
+	defer span.End()
+	var product model.Product
+	err := p.DB.Collection("products").FindOne(ctx, bson.M{"sku": sku}).Decode(&product)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return product, nil
+}
+
+// FindBySlug matches the current slug or any former one; callers compare
+// product.Slug with the requested slug to detect a redirect.
+func (p *ProductRepoI) FindBySlug(ctx context.Context, slug string) (model.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.FindBySlug")
+	defer span.End()
+	var product model.Product
+	filter := bson.M{"$or": bson.A{bson.M{"slug": slug}, bson.M{"slug_history": slug}}, "deleted_at": nil}
+	err := p.DB.Collection("products").FindOne(ctx, filter).Decode(&product)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return product, nil
+}
+
+func (p *ProductRepoI) slugTaken(ctx context.Context, candidate string, id primitive.ObjectID) (bool, error) {
+	count, err := p.DB.Collection("products").CountDocuments(ctx, bson.M{
+		"_id": bson.M{"$ne": id},
+		"$or": bson.A{bson.M{"slug": candidate}, bson.M{"slug_history": candidate}},
+	})
+	return count > 0, err
+}
+
+// RefreshSlug derives a unique slug from ProductName, appending -2, -3, ...
+// on collision with the current or former slug of another product. A slug
+// replaced because the name changed is kept in SlugHistory for redirects.
+func (p *ProductRepoI) RefreshSlug(ctx context.Context, product *model.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.RefreshSlug")
+	defer span.End()
+	base := slug.Make(product.ProductName)
+	if base == "" {
+		base = "product"
+	}
+	if product.Slug != "" && slug.HasBase(product.Slug, base) {
+		return nil
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		taken, err := p.slugTaken(ctx, candidate, product.ID)
+		if err != nil {
+			return err
+		}
+		if !taken {
+			break
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+	history := []string{}
+	for _, old := range product.SlugHistory {
+		if old != candidate {
+			history = append(history, old)
+		}
+	}
+	if product.Slug != "" {
+		history = append(history, product.Slug)
+	}
+	product.Slug = candidate
+	product.SlugHistory = history
+	return nil
+}
+
 func (p *ProductRepoI) Create(ctx context.Context, product model.Product) (model.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.Create")
+	defer span.End()
+	if err := p.RefreshSlug(ctx, &product); err != nil {
+		return model.Product{}, err
+	}
+	product.Version = 1
 	result, err := p.DB.Collection("products").InsertOne(ctx, product)
 	if err != nil {
 		return model.Product{}, err
@@ -72,36 +213,234 @@ func (p *ProductRepoI) Create(ctx context.Context, product model.Product) (model
 	return product, nil
 }
 
+// Update writes the product if it is still at product.Version and returns it
+// with the new version; see updateVersion.
 func (p *ProductRepoI) Update(ctx context.Context, product model.Product) (model.Product, error) {
-	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": product.ID}, bson.M{
-		"$set": bson.M{
-			"productname":      product.ProductName,
-			"brand":            product.Brand,
-			"quantity":         product.Quantity,
-			"price":            product.Price,
-			"productimage_url": product.ProductImage_URL,
-			"description":      product.Description,
-		}})
+	ctx, span := tracing.Start(ctx, "ProductRepo.Update")
+	defer span.End()
+	if err := p.RefreshSlug(ctx, &product); err != nil {
+		return model.Product{}, err
+	}
+	set := productFields(product)
+	set["updated_at"] = product.Updated_At
+	err := updateVersion(ctx, p.DB.Collection("products"), product.ID, product.Version, set)
 	if err != nil {
 		return model.Product{}, err
 	}
-	if result.MatchedCount == 0 {
-		return model.Product{}, mongo.ErrNoDocuments
+	product.Version++
+	return product, nil
+}
+
+// Patch writes only the fields that differ between before, as read by the
+// caller, and after. Nothing is written when nothing changed.
+func (p *ProductRepoI) Patch(ctx context.Context, before, after model.Product) (model.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.Patch")
+	defer span.End()
+	if err := p.RefreshSlug(ctx, &after); err != nil {
+		return model.Product{}, err
+	}
+	set := changedFields(productFields(before), productFields(after))
+	if len(set) == 0 {
+		return after, nil
 	}
-	return model.Product{}, nil
+	after.Updated_At = time.Now()
+	set["updated_at"] = after.Updated_At
+	if err := updateVersion(ctx, p.DB.Collection("products"), before.ID, before.Version, set); err != nil {
+		return model.Product{}, err
+	}
+	after.Version = before.Version + 1
+	return after, nil
 }
 
+// productFields are the product fields written by Update and Patch.
+func productFields(product model.Product) bson.M {
+	return bson.M{
+		"sku":              product.SKU,
+		"slug":             product.Slug,
+		"slug_history":     product.SlugHistory,
+		"productname":      product.ProductName,
+		"brand":            product.Brand,
+		"quantity":         product.Quantity,
+		"price":            product.Price,
+		"price_overrides":  product.PriceOverrides,
+		"tax_class":        product.TaxClass,
+		"productimage_url": product.ProductImage_URL,
+		"description":      product.Description,
+		"weight":           product.Weight,
+		"length":           product.Length,
+		"width":            product.Width,
+		"height":           product.Height,
+	}
+}
+
+// Delete soft-deletes the product by stamping deleted_at; it is hidden from
+// every lookup until restored or purged.
 func (p *ProductRepoI) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.Delete")
+	defer span.End()
 	ID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
-	result, err := p.DB.Collection("products").DeleteOne(ctx, bson.M{"_id": ID})
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": ID, "deleted_at": nil}, bson.M{
+		"$set": bson.M{"deleted_at": time.Now()},
+	})
 	if err != nil {
 		return err
 	}
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (p *ProductRepoI) GetDeleted(ctx context.Context) ([]model.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.GetDeleted")
+	defer span.End()
+	products := []model.Product{}
+	opts := options.Find().SetSort(bson.M{"deleted_at": -1})
+	result, err := p.DB.Collection("products").Find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (p *ProductRepoI) Restore(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.Restore")
+	defer span.End()
+	ID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": ID, "deleted_at": bson.M{"$ne": nil}}, bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Purge permanently removes products soft-deleted before the cutoff and
+// returns their IDs.
+func (p *ProductRepoI) Purge(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	ctx, span := tracing.Start(ctx, "ProductRepo.Purge")
+	defer span.End()
+	filter := bson.M{"deleted_at": bson.M{"$lt": before}}
+	ids, err := p.DB.Collection("products").Distinct(ctx, "_id", filter)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	// Products restored meanwhile no longer match the filter and are kept.
+	filter["_id"] = bson.M{"$in": ids}
+	if _, err := p.DB.Collection("products").DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+	purged := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			purged = append(purged, oid)
+		}
+	}
+	return purged, nil
+}
+
+func (p *ProductRepoI) UpdateRating(ctx context.Context, id primitive.ObjectID, average float64, count int) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.UpdateRating")
+	defer span.End()
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"rating_average": math.Round(average*100) / 100,
+			"rating_count":   count,
+		}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetPrice changes only the regular price, leaving concurrent edits of
+// other fields alone.
+func (p *ProductRepoI) SetPrice(ctx context.Context, id primitive.ObjectID, price money.Money) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.SetPrice")
+	defer span.End()
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": id, "deleted_at": nil}, bson.M{
+		"$set": bson.M{"price": price, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetSale puts a sale on the product, or removes it when sale is nil.
+func (p *ProductRepoI) SetSale(ctx context.Context, id primitive.ObjectID, sale *model.Sale) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.SetSale")
+	defer span.End()
+	update := bson.M{"$unset": bson.M{"sale": ""}, "$inc": bson.M{"version": 1}}
+	if sale != nil {
+		update = bson.M{"$set": bson.M{"sale": sale}, "$inc": bson.M{"version": 1}}
+	}
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (p *ProductRepoI) SetTranslation(ctx context.Context, id string, locale string, translation model.Translation) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.SetTranslation")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
 		return err
 	}
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"translations." + locale: translation},
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (p *ProductRepoI) DeleteTranslation(ctx context.Context, id string, locale string) error {
+	ctx, span := tracing.Start(ctx, "ProductRepo.DeleteTranslation")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := p.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$unset": bson.M{"translations." + locale: ""},
+		"$inc":   bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
 	return nil
 }
@@ -0,0 +1,199 @@
+package reponsitory
+
+import (
+	"context"
+	"errors"
+	"image-server/model"
+	"image-server/tracing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrReviewExists = errors.New("review already exists for this product")
+
+type ReviewRepo interface {
+	FindByID(ctx context.Context, id string) (model.Review, error)
+	GetByProduct(ctx context.Context, productID primitive.ObjectID) ([]model.ReviewResponse, error)
+	GetAll(ctx context.Context, status string) ([]model.ReviewResponse, error)
+	Create(ctx context.Context, review model.Review) (model.Review, error)
+	Update(ctx context.Context, review model.Review) (model.Review, error)
+	UpdateStatus(ctx context.Context, id string, status string) (model.Review, error)
+	Delete(ctx context.Context, id string) error
+	Summary(ctx context.Context, productID primitive.ObjectID) (float64, int, error)
+}
+
+type ReviewRepoI struct {
+	DB *mongo.Database
+}
+
+func NewReviewRepo(DB *mongo.Database) ReviewRepo {
+	return &ReviewRepoI{DB: DB}
+}
+
+func toReviewResponse(item model.Review) model.ReviewResponse {
+	return model.ReviewResponse{
+		ID:         item.ID.Hex(),
+		ProductID:  item.ProductID.Hex(),
+		UserID:     item.UserID.Hex(),
+		UserName:   item.UserName,
+		Rating:     item.Rating,
+		Title:      item.Title,
+		Comment:    item.Comment,
+		Status:     item.Status,
+		Created_At: item.Created_At,
+	}
+}
+
+func (r *ReviewRepoI) find(ctx context.Context, filter bson.M) ([]model.ReviewResponse, error) {
+	reviews := []model.ReviewResponse{}
+	var items []model.Review
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	result, err := r.DB.Collection("reviews").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		reviews = append(reviews, toReviewResponse(item))
+	}
+	return reviews, nil
+}
+
+func (r *ReviewRepoI) FindByID(ctx context.Context, id string) (model.Review, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.FindByID")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Review{}, err
+	}
+	var review model.Review
+	err = r.DB.Collection("reviews").FindOne(ctx, bson.M{"_id": objID}).Decode(&review)
+	if err != nil {
+		return model.Review{}, err
+	}
+	return review, nil
+}
+
+// GetByProduct returns the published reviews of a product, newest first.
+func (r *ReviewRepoI) GetByProduct(ctx context.Context, productID primitive.ObjectID) ([]model.ReviewResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.GetByProduct")
+	defer span.End()
+	return r.find(ctx, bson.M{"product_id": productID, "status": model.ReviewPublished})
+}
+
+// GetAll returns every review, optionally restricted to one status, for moderation.
+func (r *ReviewRepoI) GetAll(ctx context.Context, status string) ([]model.ReviewResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.GetAll")
+	defer span.End()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *ReviewRepoI) Create(ctx context.Context, review model.Review) (model.Review, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.Create")
+	defer span.End()
+	// The unique user_id/product_id index rejects a second review.
+	result, err := r.DB.Collection("reviews").InsertOne(ctx, review)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.Review{}, ErrReviewExists
+		}
+		return model.Review{}, err
+	}
+	review.ID = result.InsertedID.(primitive.ObjectID)
+	return review, nil
+}
+
+func (r *ReviewRepoI) Update(ctx context.Context, review model.Review) (model.Review, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.Update")
+	defer span.End()
+	result, err := r.DB.Collection("reviews").UpdateOne(ctx, bson.M{"_id": review.ID}, bson.M{
+		"$set": bson.M{
+			"rating":     review.Rating,
+			"title":      review.Title,
+			"comment":    review.Comment,
+			"updated_at": review.Updated_At,
+		}})
+	if err != nil {
+		return model.Review{}, err
+	}
+	if result.MatchedCount == 0 {
+		return model.Review{}, mongo.ErrNoDocuments
+	}
+	return review, nil
+}
+
+func (r *ReviewRepoI) UpdateStatus(ctx context.Context, id string, status string) (model.Review, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.UpdateStatus")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Review{}, err
+	}
+	var review model.Review
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.DB.Collection("reviews").FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		}}, opts).Decode(&review)
+	if err != nil {
+		return model.Review{}, err
+	}
+	return review, nil
+}
+
+func (r *ReviewRepoI) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.Delete")
+	defer span.End()
+	ID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	result, err := r.DB.Collection("reviews").DeleteOne(ctx, bson.M{"_id": ID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Summary computes the average rating and number of published reviews of a product.
+func (r *ReviewRepoI) Summary(ctx context.Context, productID primitive.ObjectID) (float64, int, error) {
+	ctx, span := tracing.Start(ctx, "ReviewRepo.Summary")
+	defer span.End()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": productID, "status": model.ReviewPublished}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := r.DB.Collection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	var result []struct {
+		Average float64 `bson:"average"`
+		Count   int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, 0, err
+	}
+	if len(result) == 0 {
+		return 0, 0, nil
+	}
+	return result[0].Average, result[0].Count, nil
+}
@@ -2,9 +2,8 @@ package reponsitory
 
 import (
 	"context"
-	"errors"
 	"image-server/model"
-	"os"
+	"image-server/tracing"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -20,55 +19,64 @@ type UserRepo interface {
 	GetAll(ctx context.Context) ([]model.UserResponse, error)
 	Create(ctx context.Context, user model.User) (model.User, error)
 	Update(ctx context.Context, user model.User) (model.User, error)
+	Patch(ctx context.Context, before, after model.User) (model.User, error)
 	Delete(ctx context.Context, id string) error
+	GetDeleted(ctx context.Context) ([]model.UserResponse, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	PromoteAdmins(ctx context.Context, emails []string) (int64, error)
 	SaveToken(user *model.User) (string, error)
 }
 type UserRepoI struct {
-	db *mongo.Database
+	db        *mongo.Database
+	secretKey string
 }
 
-func NewUserRepo(db *mongo.Database) UserRepo {
-	return &UserRepoI{db: db}
+// NewUserRepo returns a UserRepo signing tokens with secretKey.
+func NewUserRepo(db *mongo.Database, secretKey string) UserRepo {
+	return &UserRepoI{db: db, secretKey: secretKey}
 }
 func (u *UserRepoI) GetByID(ctx context.Context, ID primitive.ObjectID) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.GetByID")
+	defer span.End()
 	var user model.User
-	err := u.db.Collection("users").FindOne(ctx, bson.M{"_id": ID}).Decode(&user)
+	err := u.db.Collection("users").FindOne(ctx, bson.M{"_id": ID, "deleted_at": nil}).Decode(&user)
 	if err != nil {
 		return model.User{}, err
 	}
 	return user, nil
 }
 func (u *UserRepoI) FindByID(ctx context.Context, id string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.FindByID")
+	defer span.End()
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return model.User{}, errors.New("invalid user ID")
+		return model.User{}, err
 	}
 
 	var user model.User
-	err = u.db.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	err = u.db.Collection("users").FindOne(ctx, bson.M{"_id": objID, "deleted_at": nil}).Decode(&user)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return model.User{}, errors.New("user not found")
-		}
 		return model.User{}, err
 	}
 	return user, nil
 }
 func (u *UserRepoI) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.FindByEmail")
+	defer span.End()
 	var user model.User
-	err := u.db.Collection("users").FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := u.db.Collection("users").FindOne(ctx, bson.M{"email": email, "deleted_at": nil}).Decode(&user)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return model.User{}, errors.New("user not found")
-		}
 		return model.User{}, err
 	}
 	return user, nil
 }
 func (u *UserRepoI) GetAll(ctx context.Context) ([]model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.GetAll")
+	defer span.End()
 	var users []model.UserResponse
 	var items []model.User
-	r, err := u.db.Collection("users").Find(ctx, bson.M{})
+	r, err := u.db.Collection("users").Find(ctx, bson.M{"deleted_at": nil})
 	if err != nil {
 		return nil, err
 	}
@@ -77,16 +85,14 @@ func (u *UserRepoI) GetAll(ctx context.Context) ([]model.UserResponse, error) {
 	}
 
 	for _, item := range items {
-		users = append(users, model.UserResponse{
-			Id:        item.ID.Hex(),
-			Name:      item.Name,
-			Email:     item.Email,
-			Image_URL: item.UserImage_URL,
-		})
+		users = append(users, item.Response())
 	}
 	return users, nil
 }
 func (u *UserRepoI) Create(ctx context.Context, user model.User) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.Create")
+	defer span.End()
+	user.Version = 1
 	result, err := u.db.Collection("users").InsertOne(ctx, user)
 	if err != nil {
 		return model.User{}, err
@@ -94,42 +100,137 @@ func (u *UserRepoI) Create(ctx context.Context, user model.User) (model.User, er
 	user.ID = result.InsertedID.(primitive.ObjectID)
 	return user, nil
 }
+
+// Update writes the user if it is still at user.Version; see updateVersion.
 func (u *UserRepoI) Update(ctx context.Context, user model.User) (model.User, error) {
-	result, err := u.db.Collection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
-		"$set": bson.M{
-			"name":          user.Name,
-			"email":         user.Email,
-			"password":      user.Password,
-			"userimage_url": user.UserImage_URL,
-		}})
+	ctx, span := tracing.Start(ctx, "UserRepo.Update")
+	defer span.End()
+	err := updateVersion(ctx, u.db.Collection("users"), user.ID, user.Version, userFields(user))
 	if err != nil {
 		return model.User{}, err
 	}
-	if result.MatchedCount == 0 {
-		return model.User{}, mongo.ErrNoDocuments
-	}
 	return model.User{}, nil
 }
+
+// Patch writes only the fields that differ between before and after and
+// returns after with its new version.
+func (u *UserRepoI) Patch(ctx context.Context, before, after model.User) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.Patch")
+	defer span.End()
+	set := changedFields(userFields(before), userFields(after))
+	if len(set) == 0 {
+		return after, nil
+	}
+	if err := updateVersion(ctx, u.db.Collection("users"), before.ID, before.Version, set); err != nil {
+		return model.User{}, err
+	}
+	after.Version = before.Version + 1
+	return after, nil
+}
+
+// userFields are the user fields written by Update and Patch.
+func userFields(user model.User) bson.M {
+	return bson.M{
+		"name":          user.Name,
+		"email":         user.Email,
+		"password":      user.Password,
+		"userimage_url": user.UserImage_URL,
+		"role":          user.Role,
+	}
+}
+
+// Delete soft-deletes the user; a deleted user can no longer log in.
 func (u *UserRepoI) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "UserRepo.Delete")
+	defer span.End()
 	ID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
-	result, err := u.db.Collection("users").DeleteOne(ctx, bson.M{"_id": ID})
+	result, err := u.db.Collection("users").UpdateOne(ctx, bson.M{"_id": ID, "deleted_at": nil}, bson.M{
+		"$set": bson.M{"deleted_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+func (u *UserRepoI) GetDeleted(ctx context.Context) ([]model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.GetDeleted")
+	defer span.End()
+	users := []model.UserResponse{}
+	var items []model.User
+	r, err := u.db.Collection("users").Find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		users = append(users, item.Response())
+	}
+	return users, nil
+}
+func (u *UserRepoI) Restore(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "UserRepo.Restore")
+	defer span.End()
+	ID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
-	if result.DeletedCount == 0 {
+	result, err := u.db.Collection("users").UpdateOne(ctx, bson.M{"_id": ID, "deleted_at": bson.M{"$ne": nil}}, bson.M{
+		"$unset": bson.M{"deleted_at": ""},
+	})
+	if err != nil {
 		return err
 	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
 	return nil
 }
+
+// Purge permanently removes users soft-deleted before the cutoff.
+func (u *UserRepoI) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.Purge")
+	defer span.End()
+	result, err := u.db.Collection("users").DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// PromoteAdmins gives the admin role to the users with the given emails and
+// returns how many were changed. Promoted users get it on their next login.
+func (u *UserRepoI) PromoteAdmins(ctx context.Context, emails []string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.PromoteAdmins")
+	defer span.End()
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	result, err := u.db.Collection("users").UpdateMany(ctx, bson.M{
+		"email":      bson.M{"$in": emails},
+		"role":       bson.M{"$ne": model.RoleAdmin},
+		"deleted_at": nil,
+	}, bson.M{"$set": bson.M{"role": model.RoleAdmin}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 func (u *UserRepoI) SaveToken(user *model.User) (string, error) {
-	secret := os.Getenv("SECRET_KEY")
+	secret := u.secretKey
 	expired_At := time.Now().Add(15 * time.Minute)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.MapClaims{
-		"sub": user.Email,
-		"exp": expired_At.Unix(),
+		"sub":  user.Email,
+		"role": user.Role,
+		"exp":  expired_At.Unix(),
 	})
 
 	return token.SignedString([]byte(secret))
@@ -0,0 +1,49 @@
+package reponsitory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrVersionConflict means the document changed since the caller read it.
+var ErrVersionConflict = errors.New("document was modified by someone else")
+
+// changedFields returns the members of after that differ from before.
+func changedFields(before, after bson.M) bson.M {
+	changed := bson.M{}
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changed[field] = value
+		}
+	}
+	return changed
+}
+
+// updateVersion applies set to a live document only if it is still at
+// version, and bumps the version. It returns mongo.ErrNoDocuments when the
+// document is gone and ErrVersionConflict when it was changed meanwhile.
+func updateVersion(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, version int64, set bson.M) error {
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id, "version": version, "deleted_at": nil}, bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return ErrVersionConflict
+}
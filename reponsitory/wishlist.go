@@ -0,0 +1,117 @@
+package reponsitory
+
+import (
+	"context"
+	"image-server/model"
+	"image-server/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WishlistRepo interface {
+	GetByUser(ctx context.Context, userID primitive.ObjectID) ([]model.WishlistItem, error)
+	Add(ctx context.Context, item model.WishlistItem) (model.WishlistItem, error)
+	Remove(ctx context.Context, userID, productID primitive.ObjectID) error
+	RemoveProducts(ctx context.Context, productIDs []primitive.ObjectID) error
+	GetStockSubscribers(ctx context.Context, productID primitive.ObjectID) ([]model.WishlistItem, error)
+	ClearStockNotification(ctx context.Context, ids []primitive.ObjectID) error
+}
+
+type WishlistRepoI struct {
+	DB *mongo.Database
+}
+
+func NewWishlistRepo(DB *mongo.Database) WishlistRepo {
+	return &WishlistRepoI{DB: DB}
+}
+
+func (w *WishlistRepoI) findItems(ctx context.Context, filter bson.M) ([]model.WishlistItem, error) {
+	items := []model.WishlistItem{}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	result, err := w.DB.Collection("wishlists").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (w *WishlistRepoI) GetByUser(ctx context.Context, userID primitive.ObjectID) ([]model.WishlistItem, error) {
+	ctx, span := tracing.Start(ctx, "WishlistRepo.GetByUser")
+	defer span.End()
+	return w.findItems(ctx, bson.M{"user_id": userID})
+}
+
+// Add inserts the product into the user's wishlist, or updates the stock
+// notification flag when it is already there.
+func (w *WishlistRepoI) Add(ctx context.Context, item model.WishlistItem) (model.WishlistItem, error) {
+	ctx, span := tracing.Start(ctx, "WishlistRepo.Add")
+	defer span.End()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved model.WishlistItem
+	var err error
+	// The unique user_id/product_id index fails the upsert that loses a race
+	// to insert; retrying it updates the winner's entry instead.
+	for attempt := 0; attempt < 2; attempt++ {
+		err = w.DB.Collection("wishlists").FindOneAndUpdate(ctx,
+			bson.M{"user_id": item.UserID, "product_id": item.ProductID},
+			bson.M{
+				"$set":         bson.M{"notify_in_stock": item.NotifyInStock},
+				"$setOnInsert": bson.M{"created_at": item.Created_At},
+			}, opts).Decode(&saved)
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		return model.WishlistItem{}, err
+	}
+	return saved, nil
+}
+
+func (w *WishlistRepoI) Remove(ctx context.Context, userID, productID primitive.ObjectID) error {
+	ctx, span := tracing.Start(ctx, "WishlistRepo.Remove")
+	defer span.End()
+	result, err := w.DB.Collection("wishlists").DeleteOne(ctx, bson.M{"user_id": userID, "product_id": productID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RemoveProducts drops every wishlist entry pointing at the given products.
+func (w *WishlistRepoI) RemoveProducts(ctx context.Context, productIDs []primitive.ObjectID) error {
+	ctx, span := tracing.Start(ctx, "WishlistRepo.RemoveProducts")
+	defer span.End()
+	if len(productIDs) == 0 {
+		return nil
+	}
+	_, err := w.DB.Collection("wishlists").DeleteMany(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+	return err
+}
+
+func (w *WishlistRepoI) GetStockSubscribers(ctx context.Context, productID primitive.ObjectID) ([]model.WishlistItem, error) {
+	ctx, span := tracing.Start(ctx, "WishlistRepo.GetStockSubscribers")
+	defer span.End()
+	return w.findItems(ctx, bson.M{"product_id": productID, "notify_in_stock": true})
+}
+
+func (w *WishlistRepoI) ClearStockNotification(ctx context.Context, ids []primitive.ObjectID) error {
+	ctx, span := tracing.Start(ctx, "WishlistRepo.ClearStockNotification")
+	defer span.End()
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := w.DB.Collection("wishlists").UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$set": bson.M{"notify_in_stock": false},
+	})
+	return err
+}
@@ -0,0 +1,369 @@
+package route
+
+import (
+	"image-server/catalog"
+	"image-server/controller"
+	"image-server/model"
+	"image-server/openapi"
+	"image-server/shipping"
+	"image-server/tax"
+	"mime/multipart"
+)
+
+var (
+	localeParam   = openapi.Query("locale", "Response locale; defaults to the Accept-Language header")
+	currencyParam = openapi.Query("currency", "ISO 4217 code to convert prices to")
+	ifMatchHeader = openapi.Header("If-Match", `Version the client last saw, such as "3", or * for any`)
+	message       = openapi.Object{"data": ""}
+)
+
+// productImageForm and userImageForm are the bodies of the image
+// replacement endpoints.
+type productImageForm struct {
+	Version string                `form:"version"`
+	Image   *multipart.FileHeader `form:"image2" binding:"required"`
+}
+
+type userImageForm struct {
+	Version string                `form:"version"`
+	Image   *multipart.FileHeader `form:"image" binding:"required"`
+}
+
+// catalogImportForm is the multipart body of a catalog import.
+type catalogImportForm struct {
+	File *multipart.FileHeader `form:"file" binding:"required"`
+}
+
+// productList is the response of the product reads: prices are converted
+// when ?currency= is set.
+func productList(key string, products interface{}) openapi.Object {
+	return openapi.Object{
+		key:              products,
+		"locale":         "",
+		"currency":       "",
+		"exchange_rates": model.ExchangeRates{},
+	}
+}
+
+// endpoints documents every route registered in Route, keyed by method and
+// path. Route logs any drift between the two, and TestEndpointsMatchRoutes
+// fails on it.
+var endpoints = map[string]openapi.Endpoint{
+	"GET /healthz": {
+		Tag: "health", Summary: "Liveness probe",
+		Response: openapi.Object{"status": ""},
+	},
+	"GET /readyz": {
+		Tag: "health", Summary: "Readiness probe with per-check status",
+		Response: openapi.Object{"status": "", "checks": map[string]controller.CheckResult{}},
+	},
+	"GET /metrics": {
+		Tag: "health", Summary: "Prometheus metrics",
+		Produces: []string{"text/plain"},
+	},
+	"GET /openapi.json": {
+		Tag: "docs", Summary: "This OpenAPI document",
+		Response: map[string]interface{}{},
+	},
+	"GET /docs": {
+		Tag: "docs", Summary: "API documentation UI",
+		Produces: []string{"text/html"},
+	},
+
+	"POST /api/login": {
+		Tag: "user", Summary: "Log in and get a bearer token",
+		JSON: model.LoginRequest{}, Form: model.LoginRequest{},
+		Response: openapi.Object{"token": ""},
+	},
+	"DELETE /api/logout": {
+		Tag: "user", Summary: "Clear the token cookie",
+		Response: message,
+	},
+	"POST /api/user/create": {
+		Tag: "user", Summary: "Create a user", Auth: openapi.User,
+		Description: "Only admins may set a role other than customer.",
+		Form:        model.UserForm{},
+		Response:    openapi.Object{"fileId": "", "fileSize": 0, "user": model.User{}},
+	},
+	"PUT /api/user/update/:id": {
+		Tag: "user", Summary: "Update a user", Auth: openapi.User,
+		Description: "Empty fields are left unchanged; an image replaces the current one.",
+		Params:      []openapi.Parameter{ifMatchHeader},
+		Form:        model.UserUpdateForm{},
+		Response:    openapi.Object{"user": model.UserResponse{}},
+	},
+	"PATCH /api/user/update/:id": {
+		Tag: "user", Summary: "Patch a user", Auth: openapi.User,
+		Params:   []openapi.Parameter{ifMatchHeader},
+		Patch:    model.UserPatch{},
+		Response: openapi.Object{"user": model.UserResponse{}},
+	},
+	"PUT /api/user/image/:id": {
+		Tag: "user", Summary: "Replace the user image", Auth: openapi.User,
+		Params:   []openapi.Parameter{ifMatchHeader},
+		Form:     userImageForm{},
+		Response: openapi.Object{"user": model.UserResponse{}},
+	},
+	"DELETE /api/user/delete/:id": {
+		Tag: "user", Summary: "Soft-delete a user", Auth: openapi.User,
+		Response: message,
+	},
+	"GET /api/user/get": {
+		Tag: "user", Summary: "List users",
+		Response: openapi.Object{"users": []model.UserResponse{}},
+	},
+	"GET /image/:imageId": {
+		Tag: "user", Summary: "Download a user image",
+		Produces: []string{"image/*"},
+	},
+
+	"GET /api/product/get": {
+		Tag: "product", Summary: "List products",
+		Params:   []openapi.Parameter{localeParam, currencyParam},
+		Response: productList("products", []model.ProductResponse{}),
+	},
+	"GET /api/product/get/:id": {
+		Tag: "product", Summary: "Get a product",
+		Params:   []openapi.Parameter{localeParam, currencyParam},
+		Response: productList("product", model.ProductResponse{}),
+	},
+	"GET /api/product/slug/:slug": {
+		Tag: "product", Summary: "Get a product by slug",
+		Description: "Former slugs redirect permanently to the current one.",
+		Params:      []openapi.Parameter{localeParam, currencyParam},
+		Response:    productList("product", model.ProductResponse{}),
+	},
+	"GET /api/product/feed": {
+		Tag: "product", Summary: "RSS product feed",
+		Produces: []string{catalog.ContentType(catalog.FormatXML)},
+	},
+	"GET /image2/:imageId": {
+		Tag: "product", Summary: "Download a product image",
+		Produces: []string{"image/*"},
+	},
+	"POST /api/product/create": {
+		Tag: "product", Summary: "Create a product", Auth: openapi.User,
+		Form:     model.ProductForm{},
+		Response: openapi.Object{"fileId": "", "fileSize": 0, "product": model.Product{}},
+	},
+	"PUT /api/product/update/:id": {
+		Tag: "product", Summary: "Update a product", Auth: openapi.User,
+		Description: "Empty fields are left unchanged; an image2 upload replaces the current image.",
+		Params:      []openapi.Parameter{ifMatchHeader},
+		Form:        model.ProductUpdateForm{},
+		Response:    openapi.Object{"product": model.Product{}},
+	},
+	"PATCH /api/product/update/:id": {
+		Tag: "product", Summary: "Patch a product", Auth: openapi.User,
+		Params:   []openapi.Parameter{ifMatchHeader},
+		Patch:    model.ProductPatch{},
+		Response: openapi.Object{"product": model.Product{}},
+	},
+	"PUT /api/product/image/:id": {
+		Tag: "product", Summary: "Replace the product image", Auth: openapi.User,
+		Params:   []openapi.Parameter{ifMatchHeader},
+		Form:     productImageForm{},
+		Response: openapi.Object{"product": model.Product{}},
+	},
+	"DELETE /api/product/delete/:id": {
+		Tag: "product", Summary: "Soft-delete a product", Auth: openapi.User,
+		Response: message,
+	},
+
+	"GET /api/review/get/:productId": {
+		Tag: "review", Summary: "List the published reviews of a product",
+		Response: openapi.Object{"reviews": []model.ReviewResponse{}},
+	},
+	"POST /api/review/create": {
+		Tag: "review", Summary: "Review a product", Auth: openapi.User,
+		JSON:     model.ReviewRequest{},
+		Response: openapi.Object{"review": model.Review{}},
+	},
+	"PUT /api/review/update/:id": {
+		Tag: "review", Summary: "Edit your review", Auth: openapi.User,
+		JSON:     model.ReviewUpdateRequest{},
+		Response: openapi.Object{"review": model.Review{}},
+	},
+	"DELETE /api/review/delete/:id": {
+		Tag: "review", Summary: "Delete your review", Auth: openapi.User,
+		Response: message,
+	},
+
+	"GET /api/wishlist/get": {
+		Tag: "wishlist", Summary: "Get your wishlist", Auth: openapi.User,
+		Response: openapi.Object{"wishlist": []model.WishlistResponse{}},
+	},
+	"POST /api/wishlist/add": {
+		Tag: "wishlist", Summary: "Add a product to your wishlist", Auth: openapi.User,
+		JSON:     model.WishlistRequest{},
+		Response: openapi.Object{"item": model.WishlistItem{}},
+	},
+	"DELETE /api/wishlist/delete/:productId": {
+		Tag: "wishlist", Summary: "Remove a product from your wishlist", Auth: openapi.User,
+		Response: message,
+	},
+
+	"GET /api/me/addresses": {
+		Tag: "address", Summary: "List your addresses", Auth: openapi.User,
+		Response: openapi.Object{"addresses": []model.Address{}},
+	},
+	"POST /api/me/addresses": {
+		Tag: "address", Summary: "Add an address", Auth: openapi.User,
+		JSON:     model.AddressRequest{},
+		Response: openapi.Object{"address": model.Address{}},
+	},
+	"GET /api/me/addresses/:id": {
+		Tag: "address", Summary: "Get an address", Auth: openapi.User,
+		Response: openapi.Object{"address": model.Address{}},
+	},
+	"PUT /api/me/addresses/:id": {
+		Tag: "address", Summary: "Replace an address", Auth: openapi.User,
+		JSON:     model.AddressRequest{},
+		Response: openapi.Object{"address": model.Address{}},
+	},
+	"DELETE /api/me/addresses/:id": {
+		Tag: "address", Summary: "Delete an address", Auth: openapi.User,
+		Response: message,
+	},
+
+	"POST /api/shipping/quote": {
+		Tag: "checkout", Summary: "Quote shipping rates for a cart", Auth: openapi.User,
+		JSON:     model.CartQuoteRequest{},
+		Response: openapi.Object{"rates": []shipping.Rate{}},
+	},
+	"POST /api/tax/quote": {
+		Tag: "checkout", Summary: "Quote taxes for a cart", Auth: openapi.User,
+		JSON:     model.CartQuoteRequest{},
+		Response: openapi.Object{"tax": tax.Result{}},
+	},
+
+	"GET /api/category/get": {
+		Tag: "category", Summary: "List categories",
+		Params:   []openapi.Parameter{localeParam},
+		Response: openapi.Object{"categories": []model.CategoryResponse{}, "locale": ""},
+	},
+	"GET /api/category/get/:id": {
+		Tag: "category", Summary: "Get a category",
+		Params:   []openapi.Parameter{localeParam},
+		Response: openapi.Object{"category": model.CategoryResponse{}},
+	},
+	"GET /api/exchange-rate/get": {
+		Tag: "pricing", Summary: "Get the latest exchange rates",
+		Response: openapi.Object{"exchange_rates": model.ExchangeRates{}},
+	},
+
+	"GET /api/admin/review/get": {
+		Tag: "review", Summary: "List all reviews", Auth: openapi.Admin,
+		Params:   []openapi.Parameter{openapi.Query("status", "published or hidden")},
+		Response: openapi.Object{"reviews": []model.ReviewResponse{}},
+	},
+	"PUT /api/admin/review/status/:id": {
+		Tag: "review", Summary: "Publish or hide a review", Auth: openapi.Admin,
+		JSON:     model.ReviewStatusRequest{},
+		Response: openapi.Object{"review": model.Review{}},
+	},
+	"PUT /api/admin/exchange-rate/update": {
+		Tag: "pricing", Summary: "Replace the exchange rates", Auth: openapi.Admin,
+		JSON:     model.ExchangeRatesRequest{},
+		Response: openapi.Object{"exchange_rates": model.ExchangeRates{}},
+	},
+	"GET /api/admin/audit/get": {
+		Tag: "audit", Summary: "Search the audit log", Auth: openapi.Admin,
+		Params: []openapi.Parameter{
+			openapi.Query("entity", "product or user"),
+			openapi.Query("entity_id", ""),
+			openapi.Query("actor", "Email of the user who made the change"),
+			openapi.Query("action", ""),
+			openapi.Query("from", "RFC 3339 time"),
+			openapi.Query("to", "RFC 3339 time"),
+			openapi.Query("limit", "1 to 1000; defaults to 100"),
+		},
+		Response: openapi.Object{"entries": []model.AuditEntry{}},
+	},
+	"GET /api/admin/product/deleted": {
+		Tag: "product", Summary: "List deleted products", Auth: openapi.Admin,
+		Response: openapi.Object{"products": []model.Product{}},
+	},
+	"PUT /api/admin/product/restore/:id": {
+		Tag: "product", Summary: "Restore a deleted product", Auth: openapi.Admin,
+		Response: message,
+	},
+	"GET /api/admin/user/deleted": {
+		Tag: "user", Summary: "List deleted users", Auth: openapi.Admin,
+		Response: openapi.Object{"users": []model.UserResponse{}},
+	},
+	"PUT /api/admin/user/restore/:id": {
+		Tag: "user", Summary: "Restore a deleted user", Auth: openapi.Admin,
+		Response: message,
+	},
+	"GET /api/admin/product/price-history/:id": {
+		Tag: "pricing", Summary: "Price history of a product", Auth: openapi.Admin,
+		Response: openapi.Object{"history": []model.PriceHistory{}},
+	},
+	"GET /api/admin/product/price-schedule/:id": {
+		Tag: "pricing", Summary: "Scheduled price changes of a product", Auth: openapi.Admin,
+		Response: openapi.Object{"schedules": []model.PriceSchedule{}},
+	},
+	"POST /api/admin/product/price-schedule/:id": {
+		Tag: "pricing", Summary: "Schedule a price change or sale", Auth: openapi.Admin,
+		JSON:     model.PriceScheduleRequest{},
+		Response: openapi.Object{"schedule": model.PriceSchedule{}},
+	},
+	"DELETE /api/admin/product/price-schedule/:id": {
+		Tag: "pricing", Summary: "Cancel a pending change or end a running sale", Auth: openapi.Admin,
+		Description: "The path id is the schedule id.",
+		Response:    openapi.Object{"schedule": model.PriceSchedule{}},
+	},
+	"POST /api/admin/product/import": {
+		Tag: "catalog", Summary: "Import products from CSV or JSON lines", Auth: openapi.Admin,
+		Description: "Responds 422 with the report when any row failed.",
+		Params: []openapi.Parameter{
+			openapi.Query("format", "csv or ndjson; defaults to the file extension"),
+			openapi.Query("dry_run", "Validate without saving"),
+		},
+		Form:     catalogImportForm{},
+		Consumes: []string{"text/csv", "application/x-ndjson"},
+		Response: openapi.Object{"report": catalog.ImportReport{}},
+	},
+	"GET /api/admin/product/export": {
+		Tag: "catalog", Summary: "Export the catalog", Auth: openapi.Admin,
+		Params: []openapi.Parameter{openapi.Query("format", "csv (default), ndjson or xml")},
+		Produces: []string{
+			catalog.ContentType(catalog.FormatCSV),
+			catalog.ContentType(catalog.FormatJSON),
+			catalog.ContentType(catalog.FormatXML),
+		},
+	},
+	"PUT /api/admin/product/translation/:id/:locale": {
+		Tag: "product", Summary: "Set a product translation", Auth: openapi.Admin,
+		JSON:     model.Translation{},
+		Response: openapi.Object{"locale": "", "translation": model.Translation{}},
+	},
+	"DELETE /api/admin/product/translation/:id/:locale": {
+		Tag: "product", Summary: "Delete a product translation", Auth: openapi.Admin,
+		Response: message,
+	},
+	"POST /api/admin/category/create": {
+		Tag: "category", Summary: "Create a category", Auth: openapi.Admin,
+		JSON:     model.CategoryRequest{},
+		Response: openapi.Object{"category": model.Category{}},
+	},
+	"PUT /api/admin/category/update/:id": {
+		Tag: "category", Summary: "Update a category", Auth: openapi.Admin,
+		JSON:     model.CategoryUpdateRequest{},
+		Response: openapi.Object{"category": model.Category{}},
+	},
+	"DELETE /api/admin/category/delete/:id": {
+		Tag: "category", Summary: "Delete a category", Auth: openapi.Admin,
+		Response: message,
+	},
+	"PUT /api/admin/category/translation/:id/:locale": {
+		Tag: "category", Summary: "Set a category translation", Auth: openapi.Admin,
+		JSON:     model.Translation{},
+		Response: openapi.Object{"locale": "", "translation": model.Translation{}},
+	},
+	"DELETE /api/admin/category/translation/:id/:locale": {
+		Tag: "category", Summary: "Delete a category translation", Auth: openapi.Admin,
+		Response: message,
+	},
+}
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"context"
+	"image-server/config"
+	"image-server/openapi"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestEndpointsMatchRoutes keeps the OpenAPI document in step with the
+// router: every route is documented and every endpoint is routed.
+func TestEndpointsMatchRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The client connects lazily, so no server is needed to register routes.
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	r := gin.New()
+	if err := Route(r, client.Database("route_test"), config.Default()); err != nil {
+		t.Fatal(err)
+	}
+
+	routed := map[string]bool{}
+	var undocumented []string
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		routed[key] = true
+		if _, ok := endpoints[key]; !ok {
+			undocumented = append(undocumented, key)
+		}
+	}
+	var unrouted []string
+	for key := range endpoints {
+		if !routed[key] {
+			unrouted = append(unrouted, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unrouted)
+	for _, key := range undocumented {
+		t.Errorf("route %s has no endpoint", key)
+	}
+	for _, key := range unrouted {
+		t.Errorf("endpoint %s has no route", key)
+	}
+
+	if err := openapi.New("test", "0").Describe(r.Routes(), endpoints); err != nil {
+		t.Error(err)
+	}
+}
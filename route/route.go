@@ -1,25 +1,81 @@
 package route
 
 import (
+	"context"
+	"fmt"
+	"image-server/apierr"
+	"image-server/config"
 	"image-server/controller"
-	"image-server/db"
+	"image-server/metrics"
 	"image-server/middleware"
+	"image-server/notify"
+	"image-server/openapi"
 	"image-server/reponsitory"
-	"os"
+	"image-server/shipping"
+	"image-server/tax"
+	"image-server/tracing"
+	"log/slog"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func Route(r *gin.Engine, DB *mongo.Database) {
+// Route registers every handler on r. It fails if a configured data file
+// cannot be loaded; routes missing from the OpenAPI document are logged.
+func Route(r *gin.Engine, DB *mongo.Database, cfg *config.Config) error {
 	//All routes will be added here
-	client := db.ConnectDB()
-	ProductRepo := reponsitory.NewProductRepo(client.Database(os.Getenv("DB_NAME")))
+	ProductRepo := reponsitory.NewProductRepo(DB)
 	productController := controller.NewProductController(ProductRepo, DB)
-	UserRepo := reponsitory.NewUserRepo(client.Database(os.Getenv("DB_NAME")))
+	UserRepo := reponsitory.NewUserRepo(DB, cfg.SecretKey)
 	userController := controller.NewUserController(UserRepo, DB)
-	authMiddleware := middleware.AuthMiddleware
-	// r.Use(sessions.Sessions("session", cookie.NewStore([]byte(os.Getenv("SECRET_KEY")))))
+	ReviewRepo := reponsitory.NewReviewRepo(DB)
+	reviewController := controller.NewReviewController(ReviewRepo, ProductRepo, UserRepo)
+	WishlistRepo := reponsitory.NewWishlistRepo(DB)
+	wishlistController := controller.NewWishlistController(WishlistRepo, ProductRepo, UserRepo)
+	productController.OnBackInStock = notify.NewBackInStock(WishlistRepo, UserRepo).Notify
+	AddressRepo := reponsitory.NewAddressRepo(DB)
+	addressController := controller.NewAddressController(AddressRepo, UserRepo)
+	shippingConfig, err := shipping.LoadConfig(cfg.ShippingConfig)
+	if err != nil {
+		return fmt.Errorf("loading shipping config: %w", err)
+	}
+	shippingController := controller.NewShippingController(ProductRepo, AddressRepo, UserRepo, shipping.NewCalculator(shippingConfig))
+	taxConfig, err := tax.LoadConfig(cfg.TaxConfig)
+	if err != nil {
+		return fmt.Errorf("loading tax config: %w", err)
+	}
+	taxController := controller.NewTaxController(ProductRepo, AddressRepo, UserRepo, tax.NewCalculator(taxConfig))
+	ExchangeRateRepo := reponsitory.NewExchangeRateRepo(DB)
+	exchangeRateController := controller.NewExchangeRateController(ExchangeRateRepo)
+	productController.ExchangeRateRepo = ExchangeRateRepo
+	productController.ImportImageDir = cfg.ImportImageDir
+	productController.PublicBaseURL = cfg.PublicBaseURL
+	if cfg.ExchangeRatesFile != "" {
+		if err := exchangeRateController.LoadFile(context.Background(), cfg.ExchangeRatesFile); err != nil {
+			return fmt.Errorf("loading exchange rates: %w", err)
+		}
+	}
+	CategoryRepo := reponsitory.NewCategoryRepo(DB)
+	categoryController := controller.NewCategoryController(CategoryRepo)
+	AuditRepo := reponsitory.NewAuditRepo(DB)
+	auditController := controller.NewAuditController(AuditRepo)
+	productController.AuditRepo = AuditRepo
+	productController.PriceRepo = reponsitory.NewPriceRepo(DB)
+	userController.AuditRepo = AuditRepo
+	healthController := controller.NewHealthController(DB, cfg.HealthCheckTimeout)
+	authMiddleware := middleware.AuthMiddleware(cfg.SecretKey)
+	adminMiddleware := middleware.AdminMiddleware
+	r.Use(middleware.RequestID, tracing.HTTP, middleware.AccessLog, metrics.HTTP, middleware.Recovery)
+	r.NoRoute(func(c *gin.Context) {
+		apierr.Write(c, apierr.New(apierr.CodeNotFound, "No route matches "+c.Request.Method+" "+c.Request.URL.Path))
+	})
+	// r.Use(sessions.Sessions("session", cookie.NewStore([]byte(cfg.SecretKey))))
+	r.GET("/healthz", healthController.Healthz)
+	r.GET("/readyz", healthController.Readyz)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	spec := openapi.New("image-server API", "1.0.0")
+	r.GET("/openapi.json", spec.ServeJSON)
+	r.GET("/docs", openapi.ServeDocs)
 	r.POST("api/login", userController.Login)
 	r.DELETE("api/logout", userController.Logout)
 	auth := r.Group("/")
@@ -27,15 +83,80 @@ func Route(r *gin.Engine, DB *mongo.Database) {
 	{
 		auth.POST("/api/user/create", userController.CreateUser)
 		auth.PUT("/api/user/update/:id", userController.UpdateUser)
+		auth.PATCH("/api/user/update/:id", userController.PatchUser)
+		auth.PUT("/api/user/image/:id", userController.UpdateUserImage)
 		auth.DELETE("/api/user/delete/:id", userController.DeleteUser)
 
 		auth.POST("/api/product/create", productController.CreateProduct)
 		auth.PUT("/api/product/update/:id", productController.UpdateProduct)
+		auth.PATCH("/api/product/update/:id", productController.PatchProduct)
+		auth.PUT("/api/product/image/:id", productController.UpdateProductImage)
 		auth.DELETE("/api/product/delete/:id", productController.DeleteProduct)
+
+		auth.POST("/api/review/create", reviewController.CreateReview)
+		auth.PUT("/api/review/update/:id", reviewController.UpdateReview)
+		auth.DELETE("/api/review/delete/:id", reviewController.DeleteReview)
+
+		auth.GET("/api/wishlist/get", wishlistController.GetWishlist)
+		auth.POST("/api/wishlist/add", wishlistController.AddToWishlist)
+		auth.DELETE("/api/wishlist/delete/:productId", wishlistController.RemoveFromWishlist)
+
+		auth.GET("/api/me/addresses", addressController.GetAddresses)
+		auth.POST("/api/me/addresses", addressController.CreateAddress)
+		auth.GET("/api/me/addresses/:id", addressController.GetAddress)
+		auth.PUT("/api/me/addresses/:id", addressController.UpdateAddress)
+		auth.DELETE("/api/me/addresses/:id", addressController.DeleteAddress)
+
+		auth.POST("/api/shipping/quote", shippingController.QuoteShipping)
+		auth.POST("/api/tax/quote", taxController.QuoteTax)
+	}
+	admin := r.Group("/api/admin")
+	admin.Use(authMiddleware, adminMiddleware)
+	{
+		admin.GET("/review/get", reviewController.GetAllReview)
+		admin.PUT("/review/status/:id", reviewController.ModerateReview)
+
+		admin.PUT("/exchange-rate/update", exchangeRateController.UpdateExchangeRates)
+
+		admin.GET("/audit/get", auditController.GetAuditLog)
+
+		admin.GET("/product/deleted", productController.GetDeletedProduct)
+		admin.PUT("/product/restore/:id", productController.RestoreProduct)
+		admin.GET("/user/deleted", userController.GetDeletedUser)
+		admin.PUT("/user/restore/:id", userController.RestoreUser)
+
+		admin.GET("/product/price-history/:id", productController.GetPriceHistory)
+		admin.GET("/product/price-schedule/:id", productController.GetPriceSchedules)
+		admin.POST("/product/price-schedule/:id", productController.SchedulePrice)
+		admin.DELETE("/product/price-schedule/:id", productController.CancelPriceSchedule)
+
+		admin.POST("/product/import", productController.ImportProducts)
+		admin.GET("/product/export", productController.ExportProducts)
+		admin.PUT("/product/translation/:id/:locale", productController.SetProductTranslation)
+		admin.DELETE("/product/translation/:id/:locale", productController.DeleteProductTranslation)
+
+		admin.POST("/category/create", categoryController.CreateCategory)
+		admin.PUT("/category/update/:id", categoryController.UpdateCategory)
+		admin.DELETE("/category/delete/:id", categoryController.DeleteCategory)
+		admin.PUT("/category/translation/:id/:locale", categoryController.SetCategoryTranslation)
+		admin.DELETE("/category/translation/:id/:locale", categoryController.DeleteCategoryTranslation)
 	}
 	// r.POST("/api/user/create", userController.CreateUser)
 	r.GET("/api/user/get", userController.GetAllUser)
 	r.GET("image/:imageId", userController.ServeImage)
 	r.GET("/api/product/get", productController.GetAllProduct)
+	r.GET("/api/product/get/:id", productController.GetProduct)
+	r.GET("/api/product/slug/:slug", productController.GetProductBySlug)
+	r.GET("/api/product/feed", productController.ProductFeed)
+	r.GET("/api/category/get", categoryController.GetAllCategory)
+	r.GET("/api/category/get/:id", categoryController.GetCategory)
 	r.GET("image2/:imageId", productController.ServeImageProduct)
+	r.GET("/api/review/get/:productId", reviewController.GetProductReviews)
+	r.GET("/api/exchange-rate/get", exchangeRateController.GetExchangeRates)
+	// A stale document should not keep the API from serving; the route
+	// tests fail on it instead.
+	if err := spec.Describe(r.Routes(), endpoints); err != nil {
+		slog.Error("describing routes", "error", err)
+	}
+	return nil
 }
@@ -0,0 +1,266 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image-server/money"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrNoRate is returned when no carrier serves the destination.
+var ErrNoRate = errors.New("no shipping rate available for this destination")
+
+// volumetricDivisor converts cm³ to a chargeable weight in kg.
+const volumetricDivisor = 5000
+
+type Item struct {
+	ProductID string
+	Quantity  int
+	Price     money.Money
+	Weight    float64 // kg
+	Length    float64 // cm
+	Width     float64 // cm
+	Height    float64 // cm
+}
+
+type Destination struct {
+	Country    string
+	Region     string
+	PostalCode string
+}
+
+type Shipment struct {
+	Items       []Item
+	Destination Destination
+}
+
+func (s Shipment) Subtotal() (money.Money, error) {
+	var total money.Money
+	for _, item := range s.Items {
+		var err error
+		if total, err = total.Add(item.Price.Mul(int64(item.Quantity))); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// ChargeableWeight sums, per item, the greater of its actual and volumetric weight.
+func (s Shipment) ChargeableWeight() float64 {
+	var total float64
+	for _, item := range s.Items {
+		volumetric := item.Length * item.Width * item.Height / volumetricDivisor
+		total += math.Max(item.Weight, volumetric) * float64(item.Quantity)
+	}
+	return total
+}
+
+type Rate struct {
+	Carrier      string      `json:"carrier"`
+	Service      string      `json:"service"`
+	Zone         string      `json:"zone"`
+	Amount       money.Money `json:"amount"`
+	FreeShipping bool        `json:"free_shipping"`
+}
+
+// Carrier quotes rates for a shipment. Real carrier APIs implement this
+// interface alongside the built-in table calculators.
+type Carrier interface {
+	Name() string
+	Rates(ctx context.Context, shipment Shipment) ([]Rate, error)
+}
+
+type WeightBracket struct {
+	MaxWeight float64     `json:"max_weight"`
+	Price     money.Money `json:"price"`
+}
+
+type Zone struct {
+	Name      string   `json:"name"`
+	Countries []string `json:"countries"`
+	// FlatRate is charged per shipment by FlatRateCarrier.
+	FlatRate money.Money `json:"flat_rate"`
+	// WeightTable is used by TableRateCarrier; brackets must be sorted by
+	// MaxWeight. Weight above the last bracket is charged at PerExtraKg.
+	WeightTable []WeightBracket `json:"weight_table"`
+	PerExtraKg  money.Money     `json:"per_extra_kg"`
+}
+
+func (z Zone) matches(country string) bool {
+	for _, c := range z.Countries {
+		if c == "*" || strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// findZone returns the first zone containing the country, so specific zones
+// should be listed before a "*" catch-all.
+func findZone(zones []Zone, country string) (Zone, bool) {
+	for _, zone := range zones {
+		if zone.matches(country) {
+			return zone, true
+		}
+	}
+	return Zone{}, false
+}
+
+type FlatRateCarrier struct {
+	Zones []Zone
+}
+
+func (f *FlatRateCarrier) Name() string { return "flat_rate" }
+
+func (f *FlatRateCarrier) Rates(ctx context.Context, shipment Shipment) ([]Rate, error) {
+	zone, ok := findZone(f.Zones, shipment.Destination.Country)
+	if !ok {
+		return nil, nil
+	}
+	return []Rate{{
+		Carrier: f.Name(),
+		Service: "standard",
+		Zone:    zone.Name,
+		Amount:  zone.FlatRate,
+	}}, nil
+}
+
+type TableRateCarrier struct {
+	Zones []Zone
+}
+
+func (t *TableRateCarrier) Name() string { return "table_rate" }
+
+func (t *TableRateCarrier) Rates(ctx context.Context, shipment Shipment) ([]Rate, error) {
+	zone, ok := findZone(t.Zones, shipment.Destination.Country)
+	if !ok || len(zone.WeightTable) == 0 {
+		return nil, nil
+	}
+	weight := shipment.ChargeableWeight()
+	var amount money.Money
+	last := zone.WeightTable[len(zone.WeightTable)-1]
+	if weight > last.MaxWeight {
+		extra := zone.PerExtraKg.Mul(int64(math.Ceil(weight - last.MaxWeight)))
+		var err error
+		if amount, err = last.Price.Add(extra); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, bracket := range zone.WeightTable {
+			if weight <= bracket.MaxWeight {
+				amount = bracket.Price
+				break
+			}
+		}
+	}
+	return []Rate{{
+		Carrier: t.Name(),
+		Service: "by_weight",
+		Zone:    zone.Name,
+		Amount:  amount,
+	}}, nil
+}
+
+type Config struct {
+	// FreeShippingThreshold makes every rate free once the subtotal reaches
+	// it. Zero disables free shipping.
+	FreeShippingThreshold money.Money `json:"free_shipping_threshold"`
+	Zones                 []Zone      `json:"zones"`
+}
+
+// DefaultConfig ships domestically within Vietnam, to South-East Asia and to
+// the rest of the world.
+func DefaultConfig() Config {
+	vnd := func(amount int64) money.Money { return money.New(amount, "VND") }
+	return Config{
+		FreeShippingThreshold: vnd(1000000),
+		Zones: []Zone{
+			{
+				Name:        "domestic",
+				Countries:   []string{"VN"},
+				FlatRate:    vnd(30000),
+				WeightTable: []WeightBracket{{1, vnd(20000)}, {3, vnd(35000)}, {10, vnd(60000)}},
+				PerExtraKg:  vnd(5000),
+			},
+			{
+				Name:        "asean",
+				Countries:   []string{"TH", "LA", "KH", "MY", "SG", "ID", "PH", "MM", "BN"},
+				FlatRate:    vnd(250000),
+				WeightTable: []WeightBracket{{1, vnd(200000)}, {3, vnd(400000)}, {10, vnd(900000)}},
+				PerExtraKg:  vnd(80000),
+			},
+			{
+				Name:        "international",
+				Countries:   []string{"*"},
+				FlatRate:    vnd(600000),
+				WeightTable: []WeightBracket{{1, vnd(500000)}, {3, vnd(1000000)}, {10, vnd(2500000)}},
+				PerExtraKg:  vnd(200000),
+			},
+		},
+	}
+}
+
+// LoadConfig reads a JSON shipping configuration, falling back to
+// DefaultConfig when path is empty.
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+type Calculator struct {
+	Carriers              []Carrier
+	FreeShippingThreshold money.Money
+}
+
+// NewCalculator builds a calculator with the flat and table rate carriers.
+func NewCalculator(config Config) *Calculator {
+	return &Calculator{
+		Carriers: []Carrier{
+			&FlatRateCarrier{Zones: config.Zones},
+			&TableRateCarrier{Zones: config.Zones},
+		},
+		FreeShippingThreshold: config.FreeShippingThreshold,
+	}
+}
+
+// Quote collects rates from every carrier, cheapest first.
+func (c *Calculator) Quote(ctx context.Context, shipment Shipment) ([]Rate, error) {
+	var rates []Rate
+	for _, carrier := range c.Carriers {
+		r, err := carrier.Rates(ctx, shipment)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, r...)
+	}
+	if len(rates) == 0 {
+		return nil, ErrNoRate
+	}
+	subtotal, err := shipment.Subtotal()
+	if err != nil {
+		return nil, err
+	}
+	// The threshold only applies to carts priced in the same currency.
+	if !c.FreeShippingThreshold.IsZero() && subtotal.SameCurrency(c.FreeShippingThreshold) && subtotal.Amount >= c.FreeShippingThreshold.Amount {
+		for i := range rates {
+			rates[i].Amount = money.Zero(rates[i].Amount.Currency)
+			rates[i].FreeShipping = true
+		}
+	}
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Amount.Amount < rates[j].Amount.Amount })
+	return rates, nil
+}
@@ -0,0 +1,70 @@
+// Package slug builds URL-safe identifiers from product names.
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxLength = 80
+
+// special covers letters that carry no combining mark to strip, chiefly the
+// Vietnamese đ.
+var special = map[rune]string{
+	'đ': "d", 'Đ': "d",
+	'ø': "o", 'Ø': "o",
+	'ß': "ss",
+	'æ': "ae", 'Æ': "ae",
+	'œ': "oe", 'Œ': "oe",
+}
+
+// Make lower-cases s, removes diacritics ("Áo thun đỏ" -> "ao-thun-do") and
+// joins the remaining letters and digits with single hyphens.
+func Make(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if repl, ok := special[r]; ok {
+			b.WriteString(repl)
+			hyphen = false
+			continue
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(unicode.ToLower(r))
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	result := strings.TrimSuffix(b.String(), "-")
+	if len(result) > maxLength {
+		result = strings.TrimRight(result[:maxLength], "-")
+	}
+	return result
+}
+
+// HasBase reports whether s is base or base with a numeric suffix such as
+// "base-2", as produced when deduplicating.
+func HasBase(s, base string) bool {
+	if s == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(s, base+"-")
+	if !ok || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+// Package storage wraps the GridFS buckets that hold uploaded images.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"image-server/metrics"
+	"image-server/tracing"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ProductBucket = "products"
+	UserBucket    = "photos"
+)
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// UploadImage streams r into the named bucket under a timestamped filename
+// and returns the hex file ID stored on models as the image URL, along with
+// the number of bytes written. GridFS does not take a context, so the upload
+// is traced as one span under ctx rather than per chunk command.
+func UploadImage(ctx context.Context, db *mongo.Database, bucketName, filename string, r io.Reader) (string, int64, error) {
+	_, span := tracing.Start(ctx, "gridfs.upload", trace.WithAttributes(
+		attribute.String("gridfs.bucket", bucketName),
+		attribute.String("gridfs.filename", filename),
+	))
+	defer span.End()
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", 0, err
+	}
+	counter := &countingReader{r: r}
+	fileID, err := bucket.UploadFromStream(time.Now().Format(time.RFC3339)+"_"+filename, counter)
+	span.SetAttributes(attribute.Int64("gridfs.bytes", counter.n))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", 0, err
+	}
+	span.SetAttributes(attribute.String("gridfs.file_id", fileID.Hex()))
+	metrics.GridFSBytesUploaded.WithLabelValues(bucketName).Add(float64(counter.n))
+	return fileID.Hex(), counter.n, nil
+}
+
+// DownloadImage reads the file with the given ID from the named bucket. Like
+// UploadImage, the read is traced as one span under ctx.
+func DownloadImage(ctx context.Context, db *mongo.Database, bucketName string, fileID primitive.ObjectID) ([]byte, error) {
+	_, span := tracing.Start(ctx, "gridfs.download", trace.WithAttributes(
+		attribute.String("gridfs.bucket", bucketName),
+		attribute.String("gridfs.file_id", fileID.Hex()),
+	))
+	defer span.End()
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStream(fileID, &buf); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("gridfs.bytes", int64(buf.Len())))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package tax
+
+import (
+	"encoding/json"
+	"image-server/money"
+	"math/big"
+	"os"
+	"strings"
+)
+
+const (
+	ClassStandard = "standard"
+	ClassReduced  = "reduced"
+	ClassZero     = "zero"
+)
+
+// Rule applies Rate to a tax class in a country, optionally narrowed to a
+// region. Region-specific rules win over country-wide ones.
+type Rule struct {
+	Name     string  `json:"name"`
+	Country  string  `json:"country"`
+	Region   string  `json:"region"`
+	TaxClass string  `json:"tax_class"`
+	Rate     float64 `json:"rate"`
+}
+
+type Config struct {
+	// PricesIncludeTax tells whether catalog prices already contain tax.
+	PricesIncludeTax bool   `json:"prices_include_tax"`
+	Rules            []Rule `json:"rules"`
+}
+
+// DefaultConfig uses Vietnamese VAT with tax-inclusive catalog prices.
+func DefaultConfig() Config {
+	return Config{
+		PricesIncludeTax: true,
+		Rules: []Rule{
+			{Name: "VAT", Country: "VN", TaxClass: ClassStandard, Rate: 0.10},
+			{Name: "VAT", Country: "VN", TaxClass: ClassReduced, Rate: 0.05},
+			{Name: "VAT", Country: "VN", TaxClass: ClassZero, Rate: 0},
+		},
+	}
+}
+
+// LoadConfig reads a JSON tax configuration, falling back to DefaultConfig
+// when path is empty.
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+type Line struct {
+	ProductID string
+	TaxClass  string
+	UnitPrice money.Money
+	Quantity  int
+}
+
+type Destination struct {
+	Country string
+	Region  string
+}
+
+type LineTax struct {
+	ProductID string      `json:"product_id"`
+	TaxClass  string      `json:"tax_class"`
+	TaxName   string      `json:"tax_name"`
+	Rate      float64     `json:"rate"`
+	Net       money.Money `json:"net"`
+	Tax       money.Money `json:"tax"`
+	Gross     money.Money `json:"gross"`
+}
+
+type Result struct {
+	PricesIncludeTax bool        `json:"prices_include_tax"`
+	Lines            []LineTax   `json:"lines"`
+	Net              money.Money `json:"net"`
+	Tax              money.Money `json:"tax"`
+	Gross            money.Money `json:"gross"`
+}
+
+type Calculator struct {
+	config Config
+}
+
+func NewCalculator(config Config) *Calculator {
+	return &Calculator{config: config}
+}
+
+func (c *Calculator) PricesIncludeTax() bool {
+	return c.config.PricesIncludeTax
+}
+
+// Rule finds the rule for a tax class at a destination. A product without a
+// class is taxed as standard; no matching rule means no tax.
+func (c *Calculator) Rule(taxClass string, destination Destination) (Rule, bool) {
+	if taxClass == "" {
+		taxClass = ClassStandard
+	}
+	var countryRule Rule
+	found := false
+	for _, rule := range c.config.Rules {
+		if !strings.EqualFold(rule.Country, destination.Country) || rule.TaxClass != taxClass {
+			continue
+		}
+		if rule.Region != "" {
+			if strings.EqualFold(rule.Region, destination.Region) {
+				return rule, true
+			}
+			continue
+		}
+		if !found {
+			countryRule, found = rule, true
+		}
+	}
+	return countryRule, found
+}
+
+// Calculate computes tax per line and in total. Tax is rounded to the minor
+// unit per line, half away from zero, and the totals are sums of the rounded
+// lines so they always add up. Lines in different currencies are rejected.
+func (c *Calculator) Calculate(lines []Line, destination Destination) (Result, error) {
+	result := Result{PricesIncludeTax: c.config.PricesIncludeTax, Lines: []LineTax{}}
+	for _, line := range lines {
+		rule, _ := c.Rule(line.TaxClass, destination)
+		amount := line.UnitPrice.Mul(int64(line.Quantity))
+		lineTax := LineTax{
+			ProductID: line.ProductID,
+			TaxClass:  rule.TaxClass,
+			TaxName:   rule.Name,
+			Rate:      rule.Rate,
+		}
+		if lineTax.TaxClass == "" {
+			lineTax.TaxClass = line.TaxClass
+		}
+		rate := money.Rat(rule.Rate)
+		if c.config.PricesIncludeTax {
+			// tax = gross * rate / (1 + rate)
+			share := new(big.Rat).Quo(rate, new(big.Rat).Add(big.NewRat(1, 1), rate))
+			lineTax.Gross = amount
+			lineTax.Tax = amount.MulRat(share)
+			lineTax.Net, _ = amount.Sub(lineTax.Tax)
+		} else {
+			lineTax.Net = amount
+			lineTax.Tax = amount.MulRat(rate)
+			lineTax.Gross, _ = amount.Add(lineTax.Tax)
+		}
+		result.Lines = append(result.Lines, lineTax)
+		var err error
+		if result.Net, err = result.Net.Add(lineTax.Net); err != nil {
+			return Result{}, err
+		}
+		if result.Tax, err = result.Tax.Add(lineTax.Tax); err != nil {
+			return Result{}, err
+		}
+		if result.Gross, err = result.Gross.Add(lineTax.Gross); err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
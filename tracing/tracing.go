@@ -0,0 +1,89 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter, the global
+// tracer provider and W3C trace context propagation, plus the gin middleware
+// and MongoDB command monitor that produce the spans.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies this service on every span.
+const ServiceName = "image-server"
+
+// Exporter names accepted by NewExporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ValidExporter reports whether name is accepted by NewExporter.
+func ValidExporter(name string) bool {
+	switch strings.ToLower(name) {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+		return true
+	}
+	return false
+}
+
+// NewExporter builds the named exporter. Stdout writes pretty-printed spans
+// to w; OTLP sends them over HTTP to endpoint, or to the endpoint in the
+// standard OTEL_EXPORTER_OTLP_* variables when it is empty. None returns a
+// nil exporter: spans are still propagated but not recorded.
+func NewExporter(ctx context.Context, name, endpoint string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(name) {
+	case ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, fmt.Errorf("unknown trace exporter %q", name)
+}
+
+// Setup installs a tracer provider sending spans to exporter as the global
+// one, along with the W3C trace context and baggage propagators, and returns
+// a function flushing and stopping it. Pass sdktrace.WithSyncer options, e.g.
+// with tracetest.NewInMemoryExporter, to record spans synchronously in tests.
+func Setup(exporter sdktrace.SpanExporter, opts ...sdktrace.TracerProviderOption) func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == nil && len(opts) == 0 {
+		return func(context.Context) error { return nil }
+	}
+	res := resource.NewSchemaless(attribute.String("service.name", ServiceName))
+	opts = append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// Tracer returns the tracer of the global provider, so spans follow
+// whatever Setup installed last.
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
@@ -0,0 +1,85 @@
+package tracing_test
+
+import (
+	"context"
+	"image-server/reponsitory"
+	"image-server/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID = "00f067aa0ba902b7"
+)
+
+// TestHTTPSpans records the spans of one request in memory and checks the
+// server span continues the caller's trace and parents the repository span.
+func TestHTTPSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.Setup(nil, sdktrace.WithSyncer(exporter))
+	defer shutdown(context.Background())
+
+	// No server listens, so the repository call fails fast after starting
+	// its span.
+	client, err := mongo.Connect(context.Background(),
+		options.Client().ApplyURI("mongodb://127.0.0.1:1").SetServerSelectionTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	products := reponsitory.NewProductRepo(client.Database("tracing_test"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.HTTP)
+	r.GET("/api/product/get/:id", func(c *gin.Context) {
+		products.FindByID(c.Request.Context(), c.Param("id"))
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/product/get/65a000000000000000000001", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	server, ok := spans["GET /api/product/get/:id"]
+	if !ok {
+		t.Fatalf("no server span in %v", names(exporter.GetSpans()))
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("server span trace ID = %s, want %s from traceparent", got, traceID)
+	}
+	if got := server.Parent.SpanID().String(); got != parentSpanID || !server.Parent.IsRemote() {
+		t.Errorf("server span parent = %s, want remote %s", got, parentSpanID)
+	}
+	repo, ok := spans["ProductRepo.FindByID"]
+	if !ok {
+		t.Fatalf("no repository span in %v", names(exporter.GetSpans()))
+	}
+	if repo.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("repository span parent = %s, want server span %s", repo.Parent.SpanID(), server.SpanContext.SpanID())
+	}
+}
+
+func names(spans tracetest.SpanStubs) []string {
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}